@@ -0,0 +1,201 @@
+// Command parsestr shows what ParseStrWithOptions makes of a query string, in JSON or in
+// PHP's print_r, var_dump or var_export format so it can be diffed against real PHP dumps.
+//
+// Usage:
+//
+//	parsestr [flags] [query ...]
+//	parsestr -diff [flags] query1 query2
+//
+// Every query argument is parsed and printed in turn; without arguments the query is read
+// from standard input (a trailing newline is ignored). With -diff the two queries are parsed
+// and the changes from the first result to the second are printed (parsephp.Diff), as text
+// or with -format json as a JSON array.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/leo-stone-dot/php_parse_str_go/parsephp"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run is main with its environment passed in; it returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("parsestr", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: parsestr [flags] [query ...]")
+		fmt.Fprintln(stderr, "       parsestr -diff [flags] query1 query2")
+		fs.PrintDefaults()
+	}
+	var (
+		format  = fs.String("format", "json", "output format: json (json_encode with JSON_PRETTY_PRINT, unescaped slashes and unicode), print_r, var_dump or var_export; with -diff text (default) or json")
+		diff    = fs.Bool("diff", false, "print the changes from the first query's result to the second's")
+		php     = fs.Bool("php", false, "start from PHPOptions (& only, name mangling, PHP's default limits) instead of DefaultOptions")
+		ordered = fs.Bool("ordered", false, "keep PHP's key order (ParseStrOrderedWithOptions) instead of sorting keys")
+		seps    = fs.String("sep", "&;", "argument separators, one per character")
+		strict  = fs.Bool("strict", false, "fail on invalid percent-escapes (StrictDecode)")
+		mangle  = fs.Bool("mangle", false, "mangle '.' and ' ' in variable names like PHP (MangleNames)")
+		charset = fs.String("charset", "utf-8", "encoding of the query, e.g. windows-1252, gbk, big5 or shift_jis (Charset)")
+		badUTF8 = fs.String("invalid-utf8", "", "malformed UTF-8 in keys and values: keep, replace, drop or error (InvalidUTF8; default keep)")
+
+		maxInputVars   = fs.Int("max-input-vars", 0, "MaxInputVars (0 = unlimited)")
+		maxNesting     = fs.Int("max-nesting", 0, "MaxNestingLevel (0 = unlimited)")
+		maxIndex       = fs.Int("max-index", 0, "MaxIndex (0 = unlimited)")
+		maxKeyLength   = fs.Int("max-key-length", 0, "MaxKeyLength in bytes (0 = unlimited)")
+		maxValueLength = fs.Int("max-value-length", 0, "MaxValueLength in bytes (0 = unlimited)")
+		maxTotalBytes  = fs.Int("max-total-bytes", 0, "MaxTotalBytes (0 = unlimited)")
+		strictLimits   = fs.Bool("strict-limits", false, "fail instead of dropping input over a limit (StrictLimits)")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	formats := []string{"json", "print_r", "var_dump", "var_export"}
+	if *diff {
+		formats = []string{"text", "json"}
+		formatSet := false
+		fs.Visit(func(f *flag.Flag) { formatSet = formatSet || f.Name == "format" })
+		if !formatSet {
+			*format = "text"
+		}
+	}
+	known := false
+	for _, f := range formats {
+		known = known || f == *format
+	}
+	if !known {
+		fmt.Fprintf(stderr, "parsestr: unknown format %q\n", *format)
+		return 2
+	}
+	if *diff && fs.NArg() != 2 {
+		fmt.Fprintln(stderr, "parsestr: -diff needs two queries")
+		return 2
+	}
+	cs, ok := parsephp.LookupCharset(*charset)
+	if !ok {
+		fmt.Fprintf(stderr, "parsestr: unknown charset %q\n", *charset)
+		return 2
+	}
+
+	policies := map[string]parsephp.InvalidUTF8Policy{
+		"":        parsephp.InvalidUTF8Keep,
+		"keep":    parsephp.InvalidUTF8Keep,
+		"replace": parsephp.InvalidUTF8Replace,
+		"drop":    parsephp.InvalidUTF8Drop,
+		"error":   parsephp.InvalidUTF8Error,
+	}
+	policy, ok := policies[*badUTF8]
+	if !ok {
+		fmt.Fprintf(stderr, "parsestr: unknown -invalid-utf8 policy %q\n", *badUTF8)
+		return 2
+	}
+
+	opts := parsephp.DefaultOptions
+	if *php {
+		opts = parsephp.PHPOptions
+	}
+	// only flags given on the command line override the preset
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "sep":
+			opts.Separators = []rune(*seps)
+		case "strict":
+			opts.StrictDecode = *strict
+		case "mangle":
+			opts.MangleNames = *mangle
+		case "charset":
+			opts.Charset = cs
+		case "invalid-utf8":
+			opts.InvalidUTF8 = policy
+		case "max-input-vars":
+			opts.MaxInputVars = *maxInputVars
+		case "max-nesting":
+			opts.MaxNestingLevel = *maxNesting
+		case "max-index":
+			opts.MaxIndex = *maxIndex
+		case "max-key-length":
+			opts.MaxKeyLength = *maxKeyLength
+		case "max-value-length":
+			opts.MaxValueLength = *maxValueLength
+		case "max-total-bytes":
+			opts.MaxTotalBytes = *maxTotalBytes
+		case "strict-limits":
+			opts.StrictLimits = *strictLimits
+		}
+	})
+
+	queries := fs.Args()
+	if len(queries) == 0 {
+		in, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "parsestr: read stdin: %v\n", err)
+			return 1
+		}
+		queries = []string{strings.TrimSuffix(strings.TrimSuffix(string(in), "\n"), "\r")}
+	}
+
+	results := make([]any, 0, len(queries))
+	for _, q := range queries {
+		var result any
+		var err error
+		if *ordered {
+			result, err = parsephp.ParseStrOrderedWithOptions(q, opts)
+		} else {
+			result, err = parsephp.ParseStrWithOptions(q, opts)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "parsestr: %v\n", err)
+			return 1
+		}
+		if *diff {
+			results = append(results, result)
+			continue
+		}
+		out, err := render(result, *format)
+		if err != nil {
+			fmt.Fprintf(stderr, "parsestr: %v\n", err)
+			return 1
+		}
+		io.WriteString(stdout, out)
+	}
+	if *diff {
+		out, err := renderDiff(parsephp.Diff(results[0], results[1]), *format)
+		if err != nil {
+			fmt.Fprintf(stderr, "parsestr: %v\n", err)
+			return 1
+		}
+		io.WriteString(stdout, out)
+	}
+	return 0
+}
+
+// renderDiff formats the changes between two results; no changes print nothing as text.
+func renderDiff(changes []parsephp.Change, format string) (string, error) {
+	if format == "json" {
+		s, err := parsephp.FormatDiffJSON(changes)
+		return s + "\n", err
+	}
+	return parsephp.FormatDiff(changes), nil
+}
+
+// render formats a parse result; every format ends with a newline.
+func render(v any, format string) (string, error) {
+	switch format {
+	case "print_r":
+		return parsephp.FormatPrintR(v) // arrays end with ")\n" like PHP's print_r
+	case "var_dump":
+		return parsephp.FormatVarDump(v)
+	case "var_export":
+		s, err := parsephp.FormatVarExport(v)
+		return s + "\n", err
+	}
+	b, err := parsephp.MarshalPHPJSON(v, parsephp.JSONPrettyPrint|parsephp.JSONUnescapedSlashes|parsephp.JSONUnescapedUnicode)
+	return string(b) + "\n", err
+}
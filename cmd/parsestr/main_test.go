@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+		want  string
+	}{
+		{
+			name: "json from args",
+			args: []string{"a[]=1&a[]=2&b=x/%C3%A9"},
+			want: "{\n    \"a\": [\n        \"1\",\n        \"2\"\n    ],\n    \"b\": \"x/é\"\n}\n",
+		},
+		{
+			name:  "print_r from stdin",
+			args:  []string{"-format", "print_r"},
+			stdin: "a[0]=x&a[2]=y\n",
+			want:  "Array\n(\n    [a] => Array\n        (\n            [0] => x\n            [1] => \n            [2] => y\n        )\n\n)\n",
+		},
+		{
+			name: "var_dump with separators",
+			args: []string{"-format", "var_dump", "-sep", "|", "k=v|7=w"},
+			want: "array(2) {\n  [7]=>\n  string(1) \"w\"\n  [\"k\"]=>\n  string(1) \"v\"\n}\n",
+		},
+		{
+			name: "var_export ordered",
+			args: []string{"-format", "var_export", "-ordered", "-php", "b.c=1&a[]=x"},
+			want: "array (\n  'b_c' => '1',\n  'a' => \n  array (\n    0 => 'x',\n  ),\n)\n",
+		},
+		{
+			name: "gbk charset",
+			args: []string{"-charset", "GBK", "%B3%C7%CA%D0=%B1%B1%BE%A9"},
+			want: "{\n    \"城市\": \"北京\"\n}\n",
+		},
+		{
+			name: "replace invalid utf-8",
+			args: []string{"-invalid-utf8", "replace", "a=x%FFy"},
+			want: "{\n    \"a\": \"x\uFFFDy\"\n}\n",
+		},
+		{
+			name: "diff as text",
+			args: []string{"-diff", "a=1&b[]=x&c=3", "a=2&b[k]=x&d=4"},
+			want: "~ a = \"1\" => \"2\"\n! b: list => map\n- b[0] = \"x\"\n+ b[k] = \"x\"\n- c = \"3\"\n+ d = \"4\"\n",
+		},
+		{
+			name: "diff as json",
+			args: []string{"-diff", "-format", "json", "a=1", "a[]=1"},
+			want: "[\n    {\n        \"kind\": \"type_changed\",\n        \"path\": \"a\",\n        \"old\": \"1\",\n        \"old_type\": \"string\",\n        \"new_type\": \"list\"\n    },\n    {\n        \"kind\": \"added\",\n        \"path\": \"a[0]\",\n        \"new\": \"1\"\n    }\n]\n",
+		},
+		{
+			name: "diff of equal results",
+			args: []string{"-diff", "a=1&b=2", "b=2&a=1"},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr); code != 0 {
+			t.Fatalf("%s: exit code %d: %s", tt.name, code, stderr.String())
+		}
+		if stdout.String() != tt.want {
+			t.Fatalf("%s: got\n%s\nwant\n%s", tt.name, stdout.String(), tt.want)
+		}
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-strict", "x=%ZZ"}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("strict decode: got exit code %d, want 1", code)
+	}
+	if code := run([]string{"-max-input-vars", "1", "-strict-limits", "a=1&b=2"}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("strict limits: got exit code %d, want 1", code)
+	}
+	if code := run([]string{"-format", "yaml", "a=1"}, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("bad format: got exit code %d, want 2", code)
+	}
+	if code := run([]string{"-invalid-utf8", "error", "a=%FF"}, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("invalid utf-8: got exit code %d, want 1", code)
+	}
+	if code := run([]string{"-charset", "ebcdic", "a=1"}, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("bad charset: got exit code %d, want 2", code)
+	}
+	if code := run([]string{"-diff", "a=1"}, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("diff of one query: got exit code %d, want 2", code)
+	}
+	if code := run([]string{"-diff", "-format", "print_r", "a=1", "a=2"}, nil, &stdout, &stderr); code != 2 {
+		t.Fatalf("diff as print_r: got exit code %d, want 2", code)
+	}
+}
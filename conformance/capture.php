@@ -0,0 +1,38 @@
+<?php
+// Re-records the php.json section of every corpus case with this PHP's parse_str(),
+// or with --check only compares against it and fails on any difference.
+//
+//     php -n conformance/capture.php            # e.g. with PHP 8.x
+//     php7.4 -n conformance/capture.php --check # the other version must agree
+//
+// -n keeps ini defaults (arg_separator.input "&", max_input_vars 1000, ...), which is
+// what the corpus describes. Comments, queries and divergence sections are kept as they are.
+// A run that records or confirms every case appends PHP_VERSION to corpus/php-versions.txt.
+
+$check = in_array('--check', array_slice($argv, 1), true);
+$mismatches = 0;
+
+foreach (glob(__DIR__ . '/corpus/*.txtar') as $path) {
+    $text = file_get_contents($path);
+    if (!preg_match('/^-- query --\n(.*?)\n(?=-- |$)/ms', $text, $m)) {
+        fwrite(STDERR, "$path: missing query section\n");
+        exit(1);
+    }
+    parse_str($m[1], $result);
+    $json = json_encode($result, JSON_UNESCAPED_UNICODE | JSON_UNESCAPED_SLASHES);
+    if ($check) {
+        preg_match('/^-- php\.json --\n(.*?)\n(?=^-- |\z)/ms', $text, $want);
+        if (($want[1] ?? null) !== $json) {
+            fwrite(STDERR, basename($path) . ": PHP " . PHP_VERSION . " gives $json\n");
+            $mismatches++;
+        }
+        continue;
+    }
+    $text = preg_replace('/^-- php\.json --\n.*?(?=^-- |\z)/ms', "-- php.json --\n" . addcslashes($json, '\\$') . "\n", $text);
+    file_put_contents($path, $text);
+}
+
+if ($mismatches > 0) {
+    exit(1);
+}
+file_put_contents(__DIR__ . '/corpus/php-versions.txt', PHP_VERSION . "\n", FILE_APPEND);
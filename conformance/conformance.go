@@ -0,0 +1,209 @@
+// Package conformance checks parse_str implementations against a corpus of expected PHP results.
+//
+// Every case of the embedded corpus (corpus/*.txtar) holds a query string and the expected
+// json_encode of what PHP's parse_str() makes of it with default ini settings
+// (arg_separator.input "&", max_input_vars 1000, max_input_nesting_level 64), which is
+// what parsephp.PHPOptions mirrors. The expected outputs were written by hand from
+// php_register_variable_ex and have not been checked against a PHP binary yet: capture.php
+// re-records them with one and appends its version to corpus/php-versions.txt, which is
+// still empty.
+//
+// A case may also carry a "divergence" section: a known, documented difference between
+// parsephp and PHP. Such cases are reported like any other; callers decide how to treat them.
+package conformance
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/leo-stone-dot/php_parse_str_go/parsephp"
+)
+
+//go:embed corpus/*.txtar
+var corpus embed.FS
+
+// Case is one corpus entry.
+type Case struct {
+	Name       string // file name without .txtar
+	Comment    string // what the case is about
+	Query      string // input of parse_str
+	Want       any    // decoded php.json: the expected json_encode of PHP's result
+	Divergence string // known difference of parsephp, empty if it conforms
+}
+
+// ParseFunc is the signature of parsephp.ParseStrWithOptions.
+type ParseFunc func(query string, opts parsephp.Options) (map[string]any, error)
+
+// Result is the outcome of one case.
+type Result struct {
+	Case  Case
+	Err   error    // error returned by the parse function
+	Diffs []string // differences by bracket path, e.g. `a[b]: got "1", want "2"`
+}
+
+// Passed reports whether the parse function reproduced PHP's result.
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Diffs) == 0
+}
+
+func (r Result) String() string {
+	status := "ok"
+	if !r.Passed() {
+		status = "FAIL"
+	}
+	s := fmt.Sprintf("%s %s (%q)", status, r.Case.Name, r.Case.Query)
+	if r.Err != nil {
+		s += "\n\terror: " + r.Err.Error()
+	}
+	for _, d := range r.Diffs {
+		s += "\n\t" + d
+	}
+	if r.Case.Divergence != "" && !r.Passed() {
+		s += "\n\tknown divergence: " + r.Case.Divergence
+	}
+	return s
+}
+
+// Corpus returns the embedded cases, sorted by name.
+func Corpus() ([]Case, error) {
+	return Load(corpus, "corpus")
+}
+
+// Load reads every *.txtar file of dir in fsys as a case. Each file needs a "query"
+// section (its final newline is not part of the query) and a "php.json" section, and may
+// have a "divergence" section.
+func Load(fsys fs.FS, dir string) ([]Case, error) {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.txtar"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	cases := make([]Case, 0, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		a := parseTxtar(string(data))
+		c := Case{Name: strings.TrimSuffix(path.Base(name), ".txtar"), Comment: strings.TrimSpace(a.comment)}
+		query, ok := a.get("query")
+		if !ok {
+			return nil, fmt.Errorf("conformance: %s: missing query section", name)
+		}
+		c.Query = strings.TrimSuffix(query, "\n")
+		want, ok := a.get("php.json")
+		if !ok {
+			return nil, fmt.Errorf("conformance: %s: missing php.json section", name)
+		}
+		if err := json.Unmarshal([]byte(want), &c.Want); err != nil {
+			return nil, fmt.Errorf("conformance: %s: php.json: %w", name, err)
+		}
+		if d, ok := a.get("divergence"); ok {
+			c.Divergence = strings.Join(strings.Fields(d), " ")
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// Run parses every case with parse and opts and compares the result with PHP's.
+//
+// Both sides are compared as PHP arrays: keys and values only. Key order is not compared
+// (a Go map has none), a list equals an object with keys "0".."n-1", and nil slice holes
+// are absent keys, as in PHP.
+func Run(cases []Case, parse ParseFunc, opts parsephp.Options) []Result {
+	results := make([]Result, 0, len(cases))
+	for _, c := range cases {
+		r := Result{Case: c}
+		got, err := parse(c.Query, opts)
+		if err != nil {
+			r.Err = err
+		} else {
+			r.Diffs = diff("", normalize(got), normalize(c.Want), nil)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// normalize turns every container into a map[string]any keyed like PHP, dropping nil holes.
+func normalize(v any) any {
+	switch c := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(c))
+		for k, elem := range c {
+			if elem != nil {
+				m[k] = normalize(elem)
+			}
+		}
+		return m
+	case []any:
+		m := make(map[string]any, len(c))
+		for i, elem := range c {
+			if elem != nil {
+				m[fmt.Sprint(i)] = normalize(elem)
+			}
+		}
+		return m
+	}
+	return v
+}
+
+// diff appends the differences between normalized trees got and want at path.
+func diff(path string, got, want any, out []string) []string {
+	gm, gok := got.(map[string]any)
+	wm, wok := want.(map[string]any)
+	if !gok || !wok {
+		if gok || wok || got != want {
+			out = append(out, fmt.Sprintf("%s: got %s, want %s", display(path), render(got), render(want)))
+		}
+		return out
+	}
+	keys := make([]string, 0, len(gm)+len(wm))
+	for k := range gm {
+		keys = append(keys, k)
+	}
+	for k := range wm {
+		if _, ok := gm[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sub := k
+		if path != "" {
+			sub = path + "[" + k + "]"
+		}
+		g, gok := gm[k]
+		w, wok := wm[k]
+		switch {
+		case !wok:
+			out = append(out, fmt.Sprintf("%s: got %s, want missing", sub, render(g)))
+		case !gok:
+			out = append(out, fmt.Sprintf("%s: got missing, want %s", sub, render(w)))
+		default:
+			out = diff(sub, g, w, out)
+		}
+	}
+	return out
+}
+
+func display(path string) string {
+	if path == "" {
+		return "result"
+	}
+	return path
+}
+
+func render(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(b)
+}
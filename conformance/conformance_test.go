@@ -0,0 +1,64 @@
+package conformance
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/leo-stone-dot/php_parse_str_go/parsephp"
+)
+
+func TestCorpus_ParseStrWithOptions(t *testing.T) {
+	cases, err := Corpus()
+	if err != nil {
+		t.Fatalf("load corpus: %v", err)
+	}
+	if len(cases) == 0 {
+		t.Fatalf("empty corpus")
+	}
+	for _, r := range Run(cases, parsephp.ParseStrWithOptions, parsephp.PHPOptions) {
+		switch {
+		case r.Case.Divergence == "" && !r.Passed():
+			t.Errorf("%s", r)
+		case r.Case.Divergence != "" && r.Passed():
+			// keep the corpus honest: drop the note once parsephp agrees with PHP
+			t.Errorf("%s: passes, remove its divergence section", r.Case.Name)
+		}
+	}
+}
+
+func TestRun_ReportsDiffs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"c/one.txtar": {Data: []byte("comment\n-- query --\na[]=1&a[]=2&b=x\n-- php.json --\n{\"a\":[\"1\",\"3\"],\"c\":\"y\"}\n")},
+		"c/two.txtar": {Data: []byte("-- query --\nx=1\n-- php.json --\n{\"x\":\"1\"}\n")},
+	}
+	cases, err := Load(fsys, "c")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(cases) != 2 || cases[0].Name != "one" || cases[0].Comment != "comment" || cases[0].Query != "a[]=1&a[]=2&b=x" {
+		t.Fatalf("cases: got %+v", cases)
+	}
+
+	results := Run(cases, parsephp.ParseStrWithOptions, parsephp.DefaultOptions)
+	want := []string{`a[1]: got "2", want "3"`, `b: got "x", want missing`, `c: got missing, want "y"`}
+	if got := results[0].Diffs; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("diffs: got %q, want %q", got, want)
+	}
+	if !results[1].Passed() {
+		t.Fatalf("two: got %s", results[1])
+	}
+
+	failing := func(string, parsephp.Options) (map[string]any, error) { return nil, errors.New("boom") }
+	if r := Run(cases[1:], failing, parsephp.DefaultOptions)[0]; r.Passed() || r.Err == nil {
+		t.Fatalf("error: got %s", r)
+	}
+}
+
+func TestLoad_MissingSection(t *testing.T) {
+	fsys := fstest.MapFS{"c/bad.txtar": {Data: []byte("-- query --\nx=1\n")}}
+	if _, err := Load(fsys, "c"); err == nil {
+		t.Fatalf("got no error for a case without php.json")
+	}
+}
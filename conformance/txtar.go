@@ -0,0 +1,56 @@
+package conformance
+
+import "strings"
+
+// archive is a parsed txtar file: a free-form comment followed by named sections, each
+// introduced by a "-- name --" line (the format of golang.org/x/tools/txtar, kept
+// dependency-free here).
+type archive struct {
+	comment string
+	files   []file
+}
+
+type file struct {
+	name string
+	data string
+}
+
+// parseTxtar parses data; text before the first marker is the comment.
+func parseTxtar(data string) archive {
+	var a archive
+	cur := &a.comment
+	for len(data) > 0 {
+		line := data
+		rest := ""
+		if i := strings.IndexByte(data, '\n'); i >= 0 {
+			line, rest = data[:i], data[i+1:]
+		}
+		if name, ok := marker(line); ok {
+			a.files = append(a.files, file{name: name})
+			cur = &a.files[len(a.files)-1].data
+		} else {
+			*cur += line + "\n"
+		}
+		data = rest
+	}
+	return a
+}
+
+// marker returns the file name of a "-- name --" line.
+func marker(line string) (string, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	if !strings.HasPrefix(line, "-- ") || !strings.HasSuffix(line, " --") || len(line) < 7 {
+		return "", false
+	}
+	return strings.TrimSpace(line[3 : len(line)-3]), true
+}
+
+// get returns the content of section name.
+func (a archive) get(name string) (string, bool) {
+	for _, f := range a.files {
+		if f.name == name {
+			return f.data, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,146 @@
+package parsephp
+
+import (
+	"strconv"
+)
+
+// Key is a PHP array key. Like PHP, a key is either an integer or a string;
+// strings that spell a canonical decimal integer ("0", "42", "-7", but not "07" or "-0")
+// are stored as integers, so StringKey("3") == IntKey(3).
+type Key struct {
+	str   string
+	num   int
+	isInt bool
+}
+
+// IntKey returns an integer key.
+func IntKey(n int) Key {
+	return Key{num: n, isInt: true}
+}
+
+// StringKey returns the key PHP would use for s: an integer key when s is a canonical
+// decimal integer, otherwise a string key.
+func StringKey(s string) Key {
+	if n, ok := canonicalInt(s); ok {
+		return IntKey(n)
+	}
+	return Key{str: s}
+}
+
+// IsInt reports whether k is an integer key.
+func (k Key) IsInt() bool { return k.isInt }
+
+// Int returns the integer value of an integer key, or 0 for string keys.
+func (k Key) Int() int { return k.num }
+
+// String returns the key as PHP would print it (integers in decimal).
+func (k Key) String() string {
+	if k.isInt {
+		return strconv.Itoa(k.num)
+	}
+	return k.str
+}
+
+// canonicalInt reports whether s is a decimal integer PHP would treat as an integer array key:
+// optional '-', no leading zeros (except "0" itself), no "-0", and within the int range.
+func canonicalInt(s string) (int, bool) {
+	digits := s
+	if len(digits) > 0 && digits[0] == '-' {
+		digits = digits[1:]
+	}
+	if !isDigits(digits) {
+		return 0, false
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return 0, false
+	}
+	if digits == "0" && len(s) != len(digits) {
+		return 0, false // "-0"
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false // out of range
+	}
+	return n, true
+}
+
+// Array is an insertion-ordered PHP array: an ordered hash map whose keys are integers
+// or strings. Values produced by ParseStrOrdered are either string leaves or nested *Array.
+//
+// Iteration (Keys, Range) follows insertion order; overwriting an existing key keeps its
+// position, exactly like assigning to an existing key of a PHP array.
+// The zero value is an empty array ready to use.
+type Array struct {
+	keys   []Key
+	values map[Key]any
+	next   int // next automatic index used by Append (PHP's nNextFreeElement)
+}
+
+// NewArray returns an empty Array.
+func NewArray() *Array {
+	return &Array{}
+}
+
+// Len returns the number of elements.
+func (a *Array) Len() int {
+	return len(a.keys)
+}
+
+// Get returns the value stored under k and whether it exists.
+func (a *Array) Get(k Key) (any, bool) {
+	v, ok := a.values[k]
+	return v, ok
+}
+
+// Set stores v under k. A new key is added at the end; an existing key keeps its position.
+func (a *Array) Set(k Key, v any) {
+	if a.values == nil {
+		a.values = make(map[Key]any)
+	}
+	if _, ok := a.values[k]; !ok {
+		a.keys = append(a.keys, k)
+	}
+	a.values[k] = v
+	// Negative keys never move the automatic index (PHP < 8.3 behavior)
+	if k.isInt && k.num >= a.next {
+		a.next = k.num + 1
+	}
+}
+
+// Append stores v under the next automatic integer index (max integer key + 1, starting at 0)
+// and returns the key it used, like PHP's $a[] = v.
+func (a *Array) Append(v any) Key {
+	k := IntKey(a.next)
+	a.Set(k, v)
+	return k
+}
+
+// Delete removes k. Like PHP's unset, it does not rewind the automatic index.
+func (a *Array) Delete(k Key) {
+	if _, ok := a.values[k]; !ok {
+		return
+	}
+	delete(a.values, k)
+	for i, ek := range a.keys {
+		if ek == k {
+			a.keys = append(a.keys[:i], a.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the keys in iteration order. The returned slice is a copy.
+func (a *Array) Keys() []Key {
+	out := make([]Key, len(a.keys))
+	copy(out, a.keys)
+	return out
+}
+
+// Range calls fn for each element in iteration order until fn returns false.
+func (a *Array) Range(fn func(k Key, v any) bool) {
+	for _, k := range a.keys {
+		if !fn(k, a.values[k]) {
+			return
+		}
+	}
+}
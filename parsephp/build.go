@@ -0,0 +1,276 @@
+package parsephp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HTTPBuildQuery serializes data into a query string, mirroring PHP's http_build_query:
+// - nested containers become bracket keys (a[b][0]=x) with explicit indices
+// - nil values (including the holes growSlice leaves in slices) are skipped
+// - bool leaves become "1"/"0"; integer and float leaves use PHP's string conversion
+//
+// data must be a container: map[string]any, []any, *Array, map[string]string or []string,
+// nested arbitrarily. For any result of ParseStr/ParseStrWithOptions (with the default
+// NumericPrefix and EncodeBrackets), ParseStr(HTTPBuildQuery(result)) reproduces result.
+func HTTPBuildQuery(data any, opts BuildOptions) (string, error) {
+	if opts.ArgSeparator == "" {
+		opts.ArgSeparator = DefaultBuildOptions.ArgSeparator
+	}
+	entries, ok := containerEntries(data, true)
+	if !ok {
+		return "", fmt.Errorf("http_build_query: %w: top-level %T", ErrUnsupportedType, data)
+	}
+	var pairs []string
+	for _, e := range entries {
+		var key []byte
+		if e.isInt {
+			key = append(key, encodeComponent(opts.NumericPrefix, opts.EncType)...)
+		}
+		key = append(key, encodeComponent(e.key, opts.EncType)...)
+		var err error
+		pairs, err = appendPairs(pairs, key, []string{e.key}, e.value, opts)
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(pairs, opts.ArgSeparator), nil
+}
+
+// entry is one key/value of a container in the order it is serialized.
+type entry struct {
+	key   string
+	isInt bool
+	value any
+}
+
+// appendPairs appends the encoded pairs for value stored under the already encoded key.
+// path holds the decoded base and tokens, only used for error messages. Children extend
+// key and path in place, so a deep tree costs no copy of the prefix per level.
+func appendPairs(pairs []string, key []byte, path []string, value any, opts BuildOptions) ([]string, error) {
+	if value == nil {
+		return pairs, nil
+	}
+	if entries, ok := containerEntries(value, false); ok {
+		open, close := "[", "]"
+		if opts.EncodeBrackets {
+			open, close = "%5B", "%5D"
+		}
+		for _, e := range entries {
+			child := append(append(append(key, open...), encodeComponent(e.key, opts.EncType)...), close...)
+			var err error
+			pairs, err = appendPairs(pairs, child, append(path, e.key), e.value, opts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return pairs, nil
+	}
+	s, ok := scalarString(value)
+	if !ok {
+		return nil, fmt.Errorf("http_build_query: %s: %w: %T", pathString(path), ErrUnsupportedType, value)
+	}
+	return append(pairs, string(key)+"="+encodeComponent(s, opts.EncType)), nil
+}
+
+// containerEntries lists the entries of a supported container in serialization order.
+// Slices list their indices in order and *Array its insertion order. Maps are ordered so
+// that re-parsing rebuilds the same container (see mapEntries); the root map is simply sorted.
+func containerEntries(v any, root bool) ([]entry, bool) {
+	switch c := v.(type) {
+	case map[string]any:
+		if root {
+			keys := make([]string, 0, len(c))
+			for k := range c {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			out := make([]entry, 0, len(keys))
+			for _, k := range keys {
+				out = append(out, entry{key: k, isInt: StringKey(k).IsInt(), value: c[k]})
+			}
+			return out, true
+		}
+		return mapEntries(c), true
+	case map[string]string:
+		m := make(map[string]any, len(c))
+		for k, s := range c {
+			m[k] = s
+		}
+		return containerEntries(m, root)
+	case []any:
+		out := make([]entry, 0, len(c))
+		for i, elem := range c {
+			out = append(out, entry{key: strconv.Itoa(i), isInt: true, value: elem})
+		}
+		return out, true
+	case []string:
+		out := make([]entry, 0, len(c))
+		for i, s := range c {
+			out = append(out, entry{key: strconv.Itoa(i), isInt: true, value: s})
+		}
+		return out, true
+	case *Array:
+		out := make([]entry, 0, c.Len())
+		c.Range(func(k Key, elem any) bool {
+			out = append(out, entry{key: k.String(), isInt: k.IsInt(), value: elem})
+			return true
+		})
+		return out, true
+	}
+	return nil, false
+}
+
+// skipHoles drops the nil entries: the holes growSlice leaves are not keys in PHP.
+func skipHoles(entries []entry) []entry {
+	out := entries[:0:0]
+	for _, e := range entries {
+		if e.value != nil {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// mapEntries orders a nested map so that insert rebuilds it as a map with the same keys:
+//  1. the dense numeric prefix "0", "1", ... (a map that ensureMap converted from a slice;
+//     emitting it first recreates the slice, nil holes included)
+//  2. non-numeric keys, sorted (the first one creates or converts to the map)
+//  3. the remaining numeric keys, by value (now stored as string keys under the map)
+func mapEntries(m map[string]any) []entry {
+	out := make([]entry, 0, len(m))
+	prefix := 0
+	for {
+		k := strconv.Itoa(prefix)
+		v, ok := m[k]
+		if !ok {
+			break
+		}
+		out = append(out, entry{key: k, isInt: true, value: v})
+		prefix++
+	}
+	var named, numbered []string
+	for k := range m {
+		if isNumeric(k) {
+			if n, err := strconv.Atoi(k); err == nil && n < prefix && k == strconv.Itoa(n) {
+				continue // already emitted in the prefix
+			}
+			numbered = append(numbered, k)
+		} else {
+			named = append(named, k)
+		}
+	}
+	sort.Strings(named)
+	sort.Slice(numbered, func(i, j int) bool {
+		if len(numbered[i]) != len(numbered[j]) {
+			return len(numbered[i]) < len(numbered[j])
+		}
+		return numbered[i] < numbered[j]
+	})
+	for _, k := range named {
+		out = append(out, entry{key: k, isInt: StringKey(k).IsInt(), value: m[k]}) // e.g. "-7"
+	}
+	for _, k := range numbered {
+		out = append(out, entry{key: k, isInt: StringKey(k).IsInt(), value: m[k]})
+	}
+	return out
+}
+
+// scalarString converts a leaf to the string PHP would send for it.
+func scalarString(v any) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case Leaf:
+		return s.Value, true
+	case bool:
+		if s {
+			return "1", true
+		}
+		return "0", true
+	case int:
+		return strconv.FormatInt(int64(s), 10), true
+	case int8:
+		return strconv.FormatInt(int64(s), 10), true
+	case int16:
+		return strconv.FormatInt(int64(s), 10), true
+	case int32:
+		return strconv.FormatInt(int64(s), 10), true
+	case int64:
+		return strconv.FormatInt(s, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(s), 10), true
+	case uint8:
+		return strconv.FormatUint(uint64(s), 10), true
+	case uint16:
+		return strconv.FormatUint(uint64(s), 10), true
+	case uint32:
+		return strconv.FormatUint(uint64(s), 10), true
+	case uint64:
+		return strconv.FormatUint(s, 10), true
+	case float32:
+		return formatFloat(float64(s)), true
+	case float64:
+		return formatFloat(s), true
+	}
+	return "", false
+}
+
+// formatFloat renders f like PHP's float to string conversion: shortest round-trip digits,
+// switching to exponent form ("1.0E+25", "1.0E-5") for very large or small magnitudes.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "INF"
+	case math.IsInf(f, -1):
+		return "-INF"
+	case math.IsNaN(f):
+		return "NAN"
+	}
+	if f == 0 {
+		if math.Signbit(f) {
+			return "-0"
+		}
+		return "0"
+	}
+	e := strconv.FormatFloat(f, 'e', -1, 64) // d.ddde±XX
+	mant, expStr, _ := strings.Cut(e, "e")
+	exp, _ := strconv.Atoi(expStr)
+	if exp < -4 || exp >= 15 {
+		if !strings.Contains(mant, ".") {
+			mant += ".0"
+		}
+		sign := "+"
+		if exp < 0 {
+			sign, exp = "-", -exp
+		}
+		return mant + "E" + sign + strconv.Itoa(exp)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// encodeComponent percent-encodes s like PHP's urlencode (EncRFC1738) or rawurlencode (EncRFC3986).
+func encodeComponent(s string, enc EncType) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_', c == '.':
+			b.WriteByte(c)
+		case c == '~' && enc == EncRFC3986:
+			b.WriteByte(c)
+		case c == ' ' && enc == EncRFC1738:
+			b.WriteByte('+')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0F])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package parsephp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBuild_PHPEncoding(t *testing.T) {
+	data := map[string]any{
+		"q": "a b+c~",
+		"a": []any{"x", nil, map[string]any{"k": true}},
+		"0": "zero",
+		"f": 1.5,
+	}
+	cases := []struct {
+		name string
+		opts BuildOptions
+		want string
+	}{
+		{"default", DefaultBuildOptions, "0=zero&a[0]=x&a[2][k]=1&f=1.5&q=a+b%2Bc%7E"},
+		{"rfc3986", BuildOptions{EncType: EncRFC3986}, "0=zero&a[0]=x&a[2][k]=1&f=1.5&q=a%20b%2Bc~"},
+		{"php_brackets", BuildOptions{NumericPrefix: "n_", ArgSeparator: ";", EncodeBrackets: true},
+			"n_0=zero;a%5B0%5D=x;a%5B2%5D%5Bk%5D=1;f=1.5;q=a+b%2Bc%7E"},
+	}
+	for _, c := range cases {
+		got, err := HTTPBuildQuery(data, c.opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if got != c.want {
+			t.Fatalf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestBuild_OrderedArrayKeepsOrder(t *testing.T) {
+	arr, err := ParseStrOrdered("z=1&a[y]=2&a[x]=3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := HTTPBuildQuery(arr, DefaultBuildOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "z=1&a[y]=2&a[x]=3"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuild_UnsupportedType(t *testing.T) {
+	_, err := HTTPBuildQuery(map[string]any{"a": map[string]any{"b": struct{}{}}}, DefaultBuildOptions)
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("got %v, want ErrUnsupportedType", err)
+	}
+	if _, err := HTTPBuildQuery("scalar", DefaultBuildOptions); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("got %v, want ErrUnsupportedType", err)
+	}
+}
+
+// roundTripQueries survive ParseStr -> HTTPBuildQuery -> ParseStr unchanged; they also seed FuzzParseStr.
+var roundTripQueries = []string{
+	"a=b&a=c",
+	"a[]=b&a[]=c",
+	"a[0]=b&a[2]=c",
+	"a[b][c]=d&a[b][e]=f",
+	"a[][b]=c&a[][b]=d",
+	"a=1&a[]=2&a[]=3",
+	"q=%2B+%2520&flag",
+	"a[0][1]=x",
+	"a[b][c]=d&a[][d]=c",
+	"a[0]=x&a[b]=y&a[]=z",
+	"a[]=x&a[2]=y&a[b]=z",
+	"a[b]=x&a[2]=y",
+	"a[b]=x&a[01]=y&a[1]=z",
+	"a[%5D]=x&a[%5B]=y&b]=1&p[q=1",
+	"a[b]][][c]=x",
+	"%26k%3D=v%3Bw&城市=北京&0=zero",
+}
+
+func TestBuild_RoundTrip(t *testing.T) {
+	for _, in := range roundTripQueries {
+		first, err := ParseStr(in)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", in, err)
+		}
+		q, err := HTTPBuildQuery(first, DefaultBuildOptions)
+		if err != nil {
+			t.Fatalf("%q: build error: %v", in, err)
+		}
+		second, err := ParseStr(q)
+		if err != nil {
+			t.Fatalf("%q: reparse error: %v", q, err)
+		}
+		if !reflect.DeepEqual(first, second) {
+			t.Fatalf("%q -> %q: got %#v, want %#v", in, q, second, first)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package parsephp
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Canonicalize parses query with ParseStrWithOptions and re-encodes the result
+// deterministically, so that queries with the same parse result map to the same string
+// (cache keys, signatures): "b=2&a=1", "a=1;b=2" and "a=%31&b=2" all become "a=1&b=2".
+//
+// The output is HTTPBuildQuery's: top-level names sorted, nested keys in the order that
+// rebuilds the same containers (see HTTPBuildQuery), explicit indices instead of "[]",
+// literal brackets, the first of opts.Options.Separators ("&" by default) between pairs and
+// one percent-encoding (opts.EncType) for every key and value. Pairs without '=' come out
+// as "name=". Variables matching opts.Exclude are left out. Canonicalize is idempotent, and
+// parsing its output gives the result it encodes.
+//
+// A separator the encoding leaves literal ('-', '+', '[' ...) would split values on the
+// next parse, and the output is UTF-8, which a parse under a Charset would convert again;
+// both are rejected with an error.
+func Canonicalize(query string, opts CanonicalOptions) (string, error) {
+	sep := "&"
+	if seps := opts.Options.Separators; len(seps) > 0 {
+		sep = string(seps[0])
+		if encodeComponent(sep, opts.EncType) == sep || strings.ContainsAny(sep, "%+=[]") {
+			return "", fmt.Errorf("canonicalize: separator %q is not escaped in the output", sep)
+		}
+	}
+	if cs := opts.Options.Charset; cs != nil {
+		return "", fmt.Errorf("canonicalize: charset %s: the output is UTF-8 and does not parse back under it", cs.Name())
+	}
+	for _, pattern := range opts.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return "", fmt.Errorf("canonicalize: exclude %q: %w", pattern, err)
+		}
+	}
+	result, err := ParseStrWithOptions(query, opts.Options)
+	if err != nil {
+		return "", err
+	}
+	for name := range result {
+		for _, pattern := range opts.Exclude {
+			if ok, _ := path.Match(pattern, name); ok {
+				delete(result, name)
+				break
+			}
+		}
+	}
+	return HTTPBuildQuery(result, BuildOptions{ArgSeparator: sep, EncType: opts.EncType})
+}
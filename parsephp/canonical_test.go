@@ -0,0 +1,55 @@
+package parsephp
+
+import (
+	"errors"
+	"path"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	cases := []struct {
+		query string
+		opts  CanonicalOptions
+		want  string
+	}{
+		{"b=2&a=1", DefaultCanonicalOptions, "a=1&b=2"},
+		{"a=1;b=2", DefaultCanonicalOptions, "a=1&b=2"},
+		{"a=%31&b=2", DefaultCanonicalOptions, "a=1&b=2"},
+		{"?b&a=", DefaultCanonicalOptions, "a=&b="},
+		{"a[]=x&a[]=y&a[k]=z", DefaultCanonicalOptions, "a[0]=x&a[1]=y&a[k]=z"},
+		{"a[z]=1&a[b][]=2", DefaultCanonicalOptions, "a[b][0]=2&a[z]=1"},
+		{"q=a+b%7e%2f&x y=%E4%BD%A0", DefaultCanonicalOptions, "q=a+b%7E%2F&x+y=%E4%BD%A0"},
+		{"q=a+b%7e", CanonicalOptions{EncType: EncRFC3986}, "q=a%20b~"},
+		{"q=1&utm_source=x&utm_medium[a]=y&fbclid=z", CanonicalOptions{Exclude: []string{"utm_*", "fbclid"}}, "q=1"},
+		{"a.b=1&a_b=2", CanonicalOptions{Options: PHPOptions}, "a_b=2"},
+		{"", DefaultCanonicalOptions, ""},
+		{"b=2;a=1", CanonicalOptions{Options: Options{Separators: []rune{';'}}}, "a=1;b=2"},
+		{"a=1;b=x&y", CanonicalOptions{Options: Options{Separators: []rune{';'}}}, "a=1;b=x%26y"},
+		{"a=1&b=2", CanonicalOptions{Options: Options{Separators: []rune{'|', '&'}}}, "a=1|b=2"},
+	}
+	for _, c := range cases {
+		got, err := Canonicalize(c.query, c.opts)
+		if err != nil || got != c.want {
+			t.Fatalf("%q: got %q, %v, want %q", c.query, got, err, c.want)
+		}
+		if again, _ := Canonicalize(got, c.opts); again != got {
+			t.Fatalf("%q: not idempotent: %q -> %q", c.query, got, again)
+		}
+	}
+
+	if _, err := Canonicalize("a=1", CanonicalOptions{Exclude: []string{"[utm"}}); !errors.Is(err, path.ErrBadPattern) {
+		t.Fatalf("bad pattern: got %v", err)
+	}
+	if _, err := Canonicalize("a=%ZZ", CanonicalOptions{Options: Options{StrictDecode: true}}); !errors.Is(err, ErrInvalidPercent) {
+		t.Fatalf("strict decode: got %v", err)
+	}
+	for _, sep := range []rune{'-', '+', '['} {
+		if _, err := Canonicalize("a=1", CanonicalOptions{Options: Options{Separators: []rune{sep}}}); err == nil {
+			t.Fatalf("separator %q: got no error", sep)
+		}
+	}
+	// "a=%E9" in Latin-1 is "é", which would come out as "a=%C3%A9" and parse to "Ã©"
+	if _, err := Canonicalize("a=%E9", CanonicalOptions{Options: Options{Charset: Latin1}}); err == nil {
+		t.Fatalf("charset: got no error")
+	}
+}
@@ -0,0 +1,162 @@
+package parsephp
+
+import (
+	_ "embed"
+	"strings"
+	"unicode/utf8"
+)
+
+// Charset converts keys and values from a legacy encoding to UTF-8 (Options.Charset).
+// Decode receives the percent-decoded bytes and must return valid UTF-8; bytes it
+// cannot map should become U+FFFD.
+type Charset interface {
+	Name() string
+	Decode(s string) string
+}
+
+// Built-in charsets, generated from the unicode.org mapping tables (see gen_charsets.go).
+// GBK, Big5 and ShiftJIS are the Windows code pages 936, 950 and 932 that browsers send
+// under those names.
+var (
+	Latin1      Charset = latin1{}
+	Windows1250 Charset = &singleByteCharset{name: "windows-1250", high: &windows1250}
+	Windows1251 Charset = &singleByteCharset{name: "windows-1251", high: &windows1251}
+	Windows1252 Charset = &singleByteCharset{name: "windows-1252", high: &windows1252}
+	ISO8859_15  Charset = &singleByteCharset{name: "iso-8859-15", high: &iso8859_15}
+	KOI8R       Charset = &singleByteCharset{name: "koi8-r", high: &koi8r}
+	GBK         Charset = &doubleByteCharset{name: "gbk", table: cp936Data}
+	Big5        Charset = &doubleByteCharset{name: "big5", table: cp950Data}
+	ShiftJIS    Charset = &doubleByteCharset{name: "shift_jis", table: cp932Data}
+)
+
+var (
+	//go:embed charsetdata/cp932.bin
+	cp932Data string
+	//go:embed charsetdata/cp936.bin
+	cp936Data string
+	//go:embed charsetdata/cp950.bin
+	cp950Data string
+)
+
+// charsetAliases maps lower-case labels to the built-in charsets, following the
+// WHATWG Encoding labels where they exist.
+var charsetAliases = map[string]Charset{
+	"iso-8859-1": Latin1, "iso8859-1": Latin1, "latin1": Latin1, "l1": Latin1,
+	"windows-1250": Windows1250, "cp1250": Windows1250,
+	"windows-1251": Windows1251, "cp1251": Windows1251,
+	"windows-1252": Windows1252, "cp1252": Windows1252,
+	"iso-8859-15": ISO8859_15, "iso8859-15": ISO8859_15, "latin9": ISO8859_15,
+	"koi8-r": KOI8R, "koi8r": KOI8R,
+	"gbk": GBK, "gb2312": GBK, "cp936": GBK, "x-gbk": GBK,
+	"big5": Big5, "cp950": Big5,
+	"shift_jis": ShiftJIS, "shift-jis": ShiftJIS, "sjis": ShiftJIS, "cp932": ShiftJIS, "windows-31j": ShiftJIS,
+}
+
+// LookupCharset returns the built-in charset for a label such as "windows-1252" or "GBK"
+// (case-insensitive). "utf-8" and "" report nil, true: no conversion is needed.
+func LookupCharset(label string) (Charset, bool) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" || label == "utf-8" || label == "utf8" {
+		return nil, true
+	}
+	cs, ok := charsetAliases[label]
+	return cs, ok
+}
+
+// latin1 maps every byte to the code point of the same value.
+type latin1 struct{}
+
+func (latin1) Name() string { return "iso-8859-1" }
+
+func (latin1) Decode(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s) * 2)
+	for i := 0; i < len(s); i++ {
+		sb.WriteRune(rune(s[i]))
+	}
+	return sb.String()
+}
+
+// singleByteCharset is ASCII plus a table for bytes 0x80-0xFF.
+type singleByteCharset struct {
+	name string
+	high *[128]rune
+}
+
+func (c *singleByteCharset) Name() string { return c.name }
+
+func (c *singleByteCharset) Decode(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s) * 2)
+	for i := 0; i < len(s); i++ {
+		if b := s[i]; b < utf8.RuneSelf {
+			sb.WriteByte(b)
+		} else {
+			sb.WriteRune(c.high[b-0x80])
+		}
+	}
+	return sb.String()
+}
+
+// doubleByteCharset is ASCII plus a table of single bytes and lead/trail byte pairs in
+// the layout written by gen_charsets.go.
+type doubleByteCharset struct {
+	name  string
+	table string
+}
+
+func (c *doubleByteCharset) Name() string { return c.name }
+
+// lookup returns the code point at table index i, 0 if unmapped.
+func (c *doubleByteCharset) lookup(i int) rune {
+	return rune(c.table[2*i])<<8 | rune(c.table[2*i+1])
+}
+
+func (c *doubleByteCharset) Decode(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s) * 3 / 2)
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			sb.WriteByte(b)
+			continue
+		}
+		if r := c.lookup(int(b)); r != 0 {
+			sb.WriteRune(r)
+			continue
+		}
+		if b >= 0x81 && b <= 0xFE && i+1 < len(s) {
+			if t := s[i+1]; t >= 0x40 && t <= 0xFE {
+				r := c.lookup(256 + int(b-0x81)*191 + int(t-0x40))
+				// Like the WHATWG decoders, an unmapped pair keeps an ASCII trail byte.
+				if r != 0 || t >= utf8.RuneSelf {
+					i++
+				}
+				if r != 0 {
+					sb.WriteRune(r)
+					continue
+				}
+			}
+		}
+		sb.WriteRune(utf8.RuneError)
+	}
+	return sb.String()
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package parsephp
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCharset_BuiltIn(t *testing.T) {
+	tests := []struct {
+		charset string
+		query   string
+		want    map[string]any
+	}{
+		{"windows-1252", "caf%E9=%80%96&l[]=%FC", map[string]any{"café": "€–", "l": []any{"ü"}}},
+		{"iso-8859-1", "caf%E9=%80", map[string]any{"café": "\u0080"}},
+		{"iso-8859-15", "x=%A4", map[string]any{"x": "€"}},
+		{"windows-1251", "%E8%EC%FF=%C8%E2%E0%ED", map[string]any{"имя": "Иван"}},
+		{"koi8-r", "%C9%CD%D1=%E9%D7%C1%CE", map[string]any{"имя": "Иван"}},
+		{"GBK", "%B3%C7%CA%D0=%B1%B1%BE%A9&a[%B3%C7]=%80", map[string]any{"城市": "北京", "a": map[string]any{"城": "€"}}},
+		{"big5", "%AB%B0%A5%AB=%A5x%A5_", map[string]any{"城市": "台北"}},
+		{"shift_jis", "%96%BC%91O=%C3%BD%C4%91%BE%98Y", map[string]any{"名前": "ﾃｽﾄ太郎"}},
+		// raw (unencoded) bytes are converted too; unmapped bytes become U+FFFD
+		{"gbk", "k=\xb1\xb1+%81%20x", map[string]any{"k": "北 \uFFFD x"}},
+		{"shift_jis", "k=a%81%FFb", map[string]any{"k": "a\uFFFD\uFFFDb"}},
+	}
+	for _, tt := range tests {
+		cs, ok := LookupCharset(tt.charset)
+		if !ok || cs == nil {
+			t.Fatalf("%s: not found", tt.charset)
+		}
+		opts := DefaultOptions
+		opts.Charset = cs
+		got, err := ParseStrWithOptions(tt.query, opts)
+		if err != nil {
+			t.Fatalf("%s %q: unexpected error: %v", tt.charset, tt.query, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s %q: got %#v, want %#v", tt.charset, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCharset_Lookup(t *testing.T) {
+	if cs, ok := LookupCharset(" UTF-8 "); !ok || cs != nil {
+		t.Fatalf("utf-8: got %v, %v", cs, ok)
+	}
+	if cs, ok := LookupCharset("CP936"); !ok || cs != GBK || cs.Name() != "gbk" {
+		t.Fatalf("cp936: got %v, %v", cs, ok)
+	}
+	if _, ok := LookupCharset("ebcdic"); ok {
+		t.Fatalf("ebcdic: found")
+	}
+}
+
+// upperCharset is a stand-in for a caller-provided converter.
+type upperCharset struct{}
+
+func (upperCharset) Name() string           { return "upper" }
+func (upperCharset) Decode(s string) string { return strings.ToUpper(s) }
+
+func TestCharset_CustomAndStrict(t *testing.T) {
+	opts := DefaultOptions
+	opts.Charset = upperCharset{}
+	got, err := ParseStrWithOptions("a[b]=x%20y", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]any{"A": map[string]any{"B": "X Y"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+
+	got, err = ParseCookie("n=%E9t%E9", CookieOptions{Options: Options{Charset: Latin1}})
+	if err != nil {
+		t.Fatalf("cookie: unexpected error: %v", err)
+	}
+	if want := map[string]any{"n": "été"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("cookie: got %#v, want %#v", got, want)
+	}
+
+	opts = DefaultOptions
+	opts.Charset = Windows1252
+	opts.StrictDecode = true
+	if _, err := ParseStrWithOptions("a=%E9%G1", opts); !errors.Is(err, ErrInvalidPercent) {
+		t.Fatalf("strict: got %v, want ErrInvalidPercent", err)
+	}
+}
@@ -0,0 +1,41 @@
+package parsephp
+
+// ParseCookie parses a Cookie header the way PHP fills $_COOKIE:
+// - pairs are separated by ';' and leading spaces of names are skipped
+// - names are not decoded; values are percent-decoded without '+' -> space (rawurldecode)
+//   and otherwise kept byte-for-byte, surrounding spaces included
+// - bracket names build nested arrays with the same tokenizeKey/insert rules as ParseStr
+// - a repeated plain name keeps its first value unless LastWins is set
+func ParseCookie(header string, opts CookieOptions) (map[string]any, error) {
+	wopts := opts.Options
+	wopts.Separators = []rune{';'}
+	root := make(map[string]any)
+	w := &pairWalker{
+		opts:           wopts,
+		b:              cookieBuilder{root: root, lastWins: opts.LastWins},
+		unescapeKey:    keepEscapes,
+		unescapeValue:  rawDecode,
+		keepValueSpace: true,
+	}
+	if err := w.walk(header, false); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// cookieBuilder is mapBuilder with PHP's first-wins rule for plain top-level cookie names.
+type cookieBuilder struct {
+	root     map[string]any
+	lastWins bool
+}
+
+func (b cookieBuilder) insert(base string, tokens []string, value any) {
+	if _, exists := b.root[base]; exists && len(tokens) == 0 && !b.lastWins {
+		return
+	}
+	insert(b.root, base, tokens, value)
+}
+
+func (b cookieBuilder) remove(base string) {
+	delete(b.root, base)
+}
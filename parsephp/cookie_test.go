@@ -0,0 +1,67 @@
+package parsephp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseCookie_BracketNamesAndDecoding(t *testing.T) {
+	got, err := ParseCookie("prefs[theme]=dark;  prefs[lang]=en; sid=a+b%2Fc; list[]=1;list[]=2", CookieOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{
+		"prefs": map[string]any{"theme": "dark", "lang": "en"},
+		"sid":   "a+b/c",
+		"list":  []any{"1", "2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParseCookie_NamesNotDecodedAndFirstWins(t *testing.T) {
+	got, err := ParseCookie("a%5Bb%5D=1; a=first; a=second; ?q=1&r=2", CookieOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"a%5Bb%5D": "1", "a": "first", "?q": "1&r=2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+
+	got, err = ParseCookie("a=first; a=second", CookieOptions{LastWins: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got["a"] != "second" {
+		t.Fatalf("LastWins: got %#v, want 'second'", got["a"])
+	}
+}
+
+func TestParseRequest_CookieInRequestOrder(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?a=get", nil)
+	r.Header.Add("Cookie", "a=cookie; c[x]=1")
+	r.Header.Add("Cookie", "c[y]=2")
+	got, err := ParseRequest(r, RequestOptions{RequestOrder: "GC"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"a": "cookie", "c": map[string]any{"x": "1", "y": "2"}}
+	if !reflect.DeepEqual(got.Request, want) {
+		t.Fatalf("got %#v, want %#v", got.Request, want)
+	}
+}
+
+func TestParseCookie_ValuesNotTrimmed(t *testing.T) {
+	got, err := ParseCookie("a= x ; b=1+2%20;c=%20y", CookieOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"a": " x ", "b": "1+2 ", "c": " y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
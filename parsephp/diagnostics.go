@@ -0,0 +1,119 @@
+package parsephp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WarningKind classifies a lossy decision reported by ParseStrWithDiagnostics.
+type WarningKind string
+
+const (
+	// WarnEmptyKey: a pair without a variable name (e.g. "=x" or "[a]=x") was dropped.
+	WarnEmptyKey WarningKind = "empty_key"
+	// WarnOverwritten: a later pair replaced an existing value at the same path (last wins).
+	WarnOverwritten WarningKind = "overwritten"
+	// WarnScalarDiscarded: a scalar was thrown away to make room for an array, as in a=1&a[b]=2.
+	WarnScalarDiscarded WarningKind = "scalar_discarded"
+	// WarnContainerReplaced: a later pair replaced an array with a scalar, as in a[b]=1&a=2.
+	WarnContainerReplaced WarningKind = "container_replaced"
+	// WarnLenientDecode: an invalid percent-escape was kept literally instead of failing.
+	WarnLenientDecode WarningKind = "lenient_decode"
+	// WarnLimitDropped: a pair (or more, see Detail) was dropped by one of the Options limits.
+	WarnLimitDropped WarningKind = "limit_dropped"
+	// WarnInvalidUTF8: malformed UTF-8 was replaced or its pair dropped (Options.InvalidUTF8).
+	WarnInvalidUTF8 WarningKind = "invalid_utf8"
+)
+
+// Warning describes one lossy decision taken while parsing.
+type Warning struct {
+	Kind   WarningKind
+	Offset int    // byte offset of the raw pair in the input
+	Pair   string // raw pair that caused the decision
+	Path   string // decoded key path affected, e.g. "a[b][0]"; empty when the key is unknown
+	Lost   any    // value that was discarded (string or container), nil if nothing was stored
+	Detail string // human-readable explanation
+}
+
+func (w Warning) String() string {
+	s := fmt.Sprintf("%s at offset %d", w.Kind, w.Offset)
+	if w.Path != "" {
+		s += " (" + w.Path + ")"
+	}
+	return s + ": " + w.Detail
+}
+
+// ParseStrWithDiagnostics is ParseStrWithOptions that also returns a Warning for every
+// decision that silently loses input: dropped empty keys, overwritten values and arrays, scalars
+// discarded on conversion to an array, lenient percent-decoding and pairs dropped by
+// limits. The result is exactly the one of ParseStrWithOptions.
+// On error the warnings collected up to the failing pair are returned with it.
+func ParseStrWithDiagnostics(query string, opts Options) (map[string]any, []Warning, error) {
+	root := make(map[string]any)
+	var warnings []Warning
+	w := &pairWalker{opts: opts, warn: func(wr Warning) { warnings = append(warnings, wr) }}
+	w.b = &diagBuilder{root: root, w: w}
+	if err := w.walk(query, true); err != nil {
+		return nil, warnings, err
+	}
+	return root, warnings, nil
+}
+
+// diagBuilder is mapBuilder reporting what insert and remove lose to its walker.
+type diagBuilder struct {
+	root map[string]any
+	w    *pairWalker
+}
+
+func (b *diagBuilder) insert(base string, tokens []string, value any) {
+	insertReport(b.root, base, tokens, value, func(kind WarningKind, depth int, lost any) {
+		var detail string
+		switch kind {
+		case WarnOverwritten:
+			detail = "previous value overwritten"
+		case WarnScalarDiscarded:
+			detail = "scalar discarded to make room for an array"
+		case WarnContainerReplaced:
+			detail = "array replaced by a scalar"
+		}
+		b.w.warning(kind, keyPath(base, tokens[:depth]), lost, detail)
+	})
+}
+
+func (b *diagBuilder) remove(base string) {
+	lost := b.root[base]
+	delete(b.root, base)
+	b.w.warning(WarnLimitDropped, base, lost, "MaxNestingLevel exceeded; variable removed")
+}
+
+// keyPath renders base and tokens back in bracket notation.
+func keyPath(base string, tokens []string) string {
+	var sb strings.Builder
+	sb.WriteString(base)
+	for _, t := range tokens {
+		sb.WriteString("[" + t + "]")
+	}
+	return sb.String()
+}
+
+// hasInvalidEscape reports whether s contains a '%' not followed by two hex digits,
+// i.e. whether decode falls back to lenientDecode for it.
+func hasInvalidEscape(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' {
+			if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+				return true
+			}
+			i += 2
+		}
+	}
+	return false
+}
+
+// overwriteKind tells whether a leaf overwriting v loses an array or a previous value.
+func overwriteKind(v any) WarningKind {
+	if isContainer(v) {
+		return WarnContainerReplaced
+	}
+	return WarnOverwritten
+}
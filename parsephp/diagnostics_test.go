@@ -0,0 +1,92 @@
+package parsephp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStrWithDiagnostics_ReportsLossyDecisions(t *testing.T) {
+	in := "=x&a=1&a[b]=2&a[b]=3&l[]=1&l[c]=2&p=%ZZ&s=1&s=2"
+	got, warnings, err := ParseStrWithDiagnostics(in, DefaultOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, _ := ParseStr(in)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("result differs from ParseStr: got %#v, want %#v", got, want)
+	}
+
+	type brief struct {
+		kind   WarningKind
+		offset int
+		path   string
+		lost   any
+	}
+	var gotBrief []brief
+	for _, w := range warnings {
+		gotBrief = append(gotBrief, brief{w.Kind, w.Offset, w.Path, w.Lost})
+	}
+	wantBrief := []brief{
+		{WarnEmptyKey, 0, "", "x"},
+		{WarnScalarDiscarded, 7, "a", "1"},
+		{WarnOverwritten, 14, "a[b]", "2"},
+		{WarnLenientDecode, 34, "p", nil},
+		{WarnOverwritten, 44, "s", "1"},
+	}
+	if !reflect.DeepEqual(gotBrief, wantBrief) {
+		t.Fatalf("got %+v, want %+v", gotBrief, wantBrief)
+	}
+	if warnings[1].Pair != "a[b]=2" {
+		t.Fatalf("pair: got %q, want %q", warnings[1].Pair, "a[b]=2")
+	}
+}
+
+func TestParseStrWithDiagnostics_Limits(t *testing.T) {
+	opts := Options{MaxInputVars: 2, MaxNestingLevel: 1}
+	_, warnings, err := ParseStrWithDiagnostics("a[x]=1&a[b][c]=2&d=3", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("got %d warnings, want 2: %v", len(warnings), warnings)
+	}
+	if w := warnings[0]; w.Kind != WarnLimitDropped || w.Path != "a" || !reflect.DeepEqual(w.Lost, map[string]any{"x": "1"}) {
+		t.Fatalf("nesting: got %+v", w)
+	}
+	if w := warnings[1]; w.Kind != WarnLimitDropped || w.Offset != 17 || w.Pair != "d=3" {
+		t.Fatalf("input vars: got %+v", w)
+	}
+}
+
+func TestParseStrWithDiagnostics_ListOverwrite(t *testing.T) {
+	_, warnings, err := ParseStrWithDiagnostics("a[]=x&a[0]=y&a[0][k]=z", DefaultOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var kinds []WarningKind
+	var paths []string
+	for _, w := range warnings {
+		kinds = append(kinds, w.Kind)
+		paths = append(paths, w.Path)
+	}
+	if want := []WarningKind{WarnOverwritten, WarnScalarDiscarded}; !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("kinds: got %v, want %v", kinds, want)
+	}
+	if want := []string{"a[0]", "a[0]"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("paths: got %v, want %v", paths, want)
+	}
+}
+
+func TestParseStrWithDiagnostics_ContainerReplaced(t *testing.T) {
+	_, warnings, err := ParseStrWithDiagnostics("a[b]=1&a=2&l[0][k]=x&l[0]=y", DefaultOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Warning{
+		{Kind: WarnContainerReplaced, Offset: 7, Pair: "a=2", Path: "a", Lost: map[string]any{"b": "1"}, Detail: "array replaced by a scalar"},
+		{Kind: WarnContainerReplaced, Offset: 21, Pair: "l[0]=y", Path: "l[0]", Lost: map[string]any{"k": "x"}, Detail: "array replaced by a scalar"},
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Fatalf("got %+v, want %+v", warnings, want)
+	}
+}
@@ -0,0 +1,212 @@
+package parsephp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind classifies a difference reported by Diff.
+type ChangeKind string
+
+const (
+	// ChangeAdded: a leaf exists only in b.
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved: a leaf exists only in a.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeModified: a leaf has different values in a and b.
+	ChangeModified ChangeKind = "changed"
+	// ChangeType: the value at Path changed type, e.g. string -> map or list -> map.
+	ChangeType ChangeKind = "type_changed"
+)
+
+// Change is one difference between two parse results.
+type Change struct {
+	Kind    ChangeKind `json:"kind"`
+	Path    string     `json:"path"`               // bracket path, e.g. "a[b][0]"; empty for the results themselves
+	Old     any        `json:"old,omitempty"`      // value in a, for removed and changed leaves (and a leaf that changed type)
+	New     any        `json:"new,omitempty"`      // value in b, for added and changed leaves (and a leaf that changed type)
+	OldType string     `json:"old_type,omitempty"` // for ChangeType: "string", "list", "map", "array" (*Array), ...
+	NewType string     `json:"new_type,omitempty"`
+}
+
+// Diff compares two parse results (ParseStr trees or *Array) and reports their differences
+// by bracket path. Containers are compared key by key, so a list and a map holding the
+// same keys differ only by a ChangeType. When a leaf turns into an array or back, the
+// ChangeType is followed by the leaves added or removed below it. nil slice holes are
+// absent keys. Changes come in the order of the entries of a, then of those only in b
+// (see HTTPBuildQuery for the order of map keys); a Leaf is compared and reported by its Value.
+func Diff(a, b any) []Change {
+	var out []Change
+	diffValue("", a, b, &out)
+	return out
+}
+
+func diffValue(path string, a, b any, out *[]Change) {
+	switch {
+	case a == nil && b == nil:
+		return
+	case a == nil:
+		diffLeaves(ChangeAdded, path, b, out)
+		return
+	case b == nil:
+		diffLeaves(ChangeRemoved, path, a, out)
+		return
+	}
+	ta, tb := diffType(a), diffType(b)
+	ea, aIsContainer := containerEntries(a, false)
+	eb, bIsContainer := containerEntries(b, false)
+	if ta != tb {
+		c := Change{Kind: ChangeType, Path: path, OldType: ta, NewType: tb}
+		if !aIsContainer {
+			c.Old = leafValue(a)
+		}
+		if !bIsContainer {
+			c.New = leafValue(b)
+		}
+		*out = append(*out, c)
+		if !aIsContainer || !bIsContainer {
+			if aIsContainer {
+				diffLeaves(ChangeRemoved, path, a, out)
+			}
+			if bIsContainer {
+				diffLeaves(ChangeAdded, path, b, out)
+			}
+			return
+		}
+	}
+	if aIsContainer && bIsContainer {
+		inB := make(map[string]any, len(eb))
+		for _, e := range eb {
+			inB[StringKey(e.key).String()] = e.value
+		}
+		inA := make(map[string]bool, len(ea))
+		for _, e := range ea {
+			k := StringKey(e.key).String()
+			inA[k] = true
+			diffValue(joinPath(path, e.key), e.value, inB[k], out)
+		}
+		for _, e := range eb {
+			if !inA[StringKey(e.key).String()] {
+				diffValue(joinPath(path, e.key), nil, e.value, out)
+			}
+		}
+		return
+	}
+	if !leafEqual(a, b) {
+		*out = append(*out, Change{Kind: ChangeModified, Path: path, Old: leafValue(a), New: leafValue(b)})
+	}
+}
+
+// diffLeaves reports every leaf of v as kind (ChangeAdded or ChangeRemoved).
+// An empty array is reported as a value of its own.
+func diffLeaves(kind ChangeKind, path string, v any, out *[]Change) {
+	entries, ok := containerEntries(v, false)
+	if ok {
+		found := false
+		for _, e := range entries {
+			if e.value != nil {
+				found = true
+				diffLeaves(kind, joinPath(path, e.key), e.value, out)
+			}
+		}
+		if found {
+			return
+		}
+	}
+	c := Change{Kind: kind, Path: path}
+	if kind == ChangeAdded {
+		c.New = leafValue(v)
+	} else {
+		c.Old = leafValue(v)
+	}
+	*out = append(*out, c)
+}
+
+// diffType names the type of v for ChangeType.
+func diffType(v any) string {
+	switch v.(type) {
+	case []any, []string:
+		return "list"
+	case map[string]any, map[string]string:
+		return "map"
+	case *Array:
+		return "array"
+	}
+	if typ, _, err := phpLeaf("diff", nil, v); err == nil {
+		return typ
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func leafValue(v any) any {
+	if l, ok := v.(Leaf); ok {
+		return l.Value
+	}
+	return v
+}
+
+func leafEqual(a, b any) bool {
+	_, sa, errA := phpLeaf("diff", nil, a)
+	_, sb, errB := phpLeaf("diff", nil, b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return sa == sb
+}
+
+// FormatDiff renders changes one per line, strings quoted: `+ a[x] = "2"` for an added leaf,
+// `- a[y] = "1"` for a removed one, `~ a[z] = "1" => "2"` for a changed one and
+// `! b: string "1" => map` for a type change. The path of the results themselves is "(root)".
+func FormatDiff(changes []Change) string {
+	var sb strings.Builder
+	for _, c := range changes {
+		path := c.Path
+		if path == "" {
+			path = "(root)"
+		}
+		switch c.Kind {
+		case ChangeAdded:
+			fmt.Fprintf(&sb, "+ %s = %s\n", path, diffText(c.New))
+		case ChangeRemoved:
+			fmt.Fprintf(&sb, "- %s = %s\n", path, diffText(c.Old))
+		case ChangeModified:
+			fmt.Fprintf(&sb, "~ %s = %s => %s\n", path, diffText(c.Old), diffText(c.New))
+		case ChangeType:
+			from, to := c.OldType, c.NewType
+			if c.Old != nil {
+				from += " " + diffText(c.Old)
+			}
+			if c.New != nil {
+				to += " " + diffText(c.New)
+			}
+			fmt.Fprintf(&sb, "! %s: %s => %s\n", path, from, to)
+		}
+	}
+	return sb.String()
+}
+
+func diffText(v any) string {
+	switch c := v.(type) {
+	case string:
+		return strconv.Quote(c)
+	case []any, map[string]any, *Array:
+		return "[]"
+	}
+	return fmt.Sprint(v)
+}
+
+// FormatDiffJSON renders changes as an indented JSON array of
+// {"kind", "path", "old", "new", "old_type", "new_type"} objects, omitting empty fields.
+func FormatDiffJSON(changes []Change) (string, error) {
+	if changes == nil {
+		changes = []Change{}
+	}
+	b, err := json.MarshalIndent(changes, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,91 @@
+package parsephp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := mustParse(t, "a=1&b[x]=1&b[y]=2&c[]=1&c[]=2&d=1&e[]=1&same[k]=v")
+	b := mustParse(t, "a=2&b[x]=1&c[]=1&c[5]=3&d[x]=1&e[k]=1&f=new&same[k]=v")
+	want := []Change{
+		{Kind: ChangeModified, Path: "a", Old: "1", New: "2"},
+		{Kind: ChangeRemoved, Path: "b[y]", Old: "2"},
+		{Kind: ChangeRemoved, Path: "c[1]", Old: "2"},
+		{Kind: ChangeAdded, Path: "c[5]", New: "3"},
+		{Kind: ChangeType, Path: "d", OldType: "string", NewType: "map", Old: "1"},
+		{Kind: ChangeAdded, Path: "d[x]", New: "1"},
+		{Kind: ChangeType, Path: "e", OldType: "list", NewType: "map"},
+		{Kind: ChangeRemoved, Path: "e[0]", Old: "1"},
+		{Kind: ChangeAdded, Path: "e[k]", New: "1"},
+		{Kind: ChangeAdded, Path: "f", New: "new"},
+	}
+	got := Diff(a, b)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v\nwant %#v", got, want)
+	}
+	if changes := Diff(a, mustParse(t, "same[k]=v&e[]=1&d=1&c[]=1&c[]=2&b[y]=2&b[x]=1&a=1")); len(changes) != 0 {
+		t.Fatalf("equal results: got %#v", changes)
+	}
+
+	// Ordered results compare the same way; the array -> string change lists the removed leaves.
+	oa, _ := ParseStrOrdered("a[x]=1&a[y]=2")
+	ob, _ := ParseStrOrdered("a=1")
+	want = []Change{
+		{Kind: ChangeType, Path: "a", OldType: "array", NewType: "string", New: "1"},
+		{Kind: ChangeRemoved, Path: "a[x]", Old: "1"},
+		{Kind: ChangeRemoved, Path: "a[y]", Old: "2"},
+	}
+	if got := Diff(oa, ob); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ordered: got %#v\nwant %#v", got, want)
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	changes := Diff(mustParse(t, "a=1&b=x&c[]=1"), mustParse(t, "a=2&c=1&d[k]=\"q\""))
+	text := `~ a = "1" => "2"
+- b = "x"
+! c: list => string "1"
+- c[0] = "1"
++ d[k] = "\"q\""
+`
+	if got := FormatDiff(changes); got != text {
+		t.Fatalf("text: got\n%s\nwant\n%s", got, text)
+	}
+	js := `[
+    {
+        "kind": "changed",
+        "path": "a",
+        "old": "1",
+        "new": "2"
+    },
+    {
+        "kind": "removed",
+        "path": "b",
+        "old": "x"
+    },
+    {
+        "kind": "type_changed",
+        "path": "c",
+        "new": "1",
+        "old_type": "list",
+        "new_type": "string"
+    },
+    {
+        "kind": "removed",
+        "path": "c[0]",
+        "old": "1"
+    },
+    {
+        "kind": "added",
+        "path": "d[k]",
+        "new": "\"q\""
+    }
+]`
+	if got, err := FormatDiffJSON(changes); err != nil || got != js {
+		t.Fatalf("json: got\n%s, %v\nwant\n%s", got, err, js)
+	}
+	if got, _ := FormatDiffJSON(nil); got != "[]" {
+		t.Fatalf("json of no changes: got %s", got)
+	}
+}
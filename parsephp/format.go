@@ -0,0 +1,238 @@
+package parsephp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FormatPrintR renders v byte-for-byte like PHP 8's print_r. v is a ParseStr-style tree
+// (map[string]any, []any, *Array, map[string]string, []string) whose leaves are strings,
+// nil, bools, integers or floats; other leaves yield an error wrapping ErrUnsupportedType.
+//
+// Container entries come in the order HTTPBuildQuery uses: slices by index, *Array in
+// insertion order, top-level maps sorted by key and nested maps dense indices first.
+// Keys that PHP would store as integers ("0", "12") are shown as integers, nil slice
+// holes as NULL.
+func FormatPrintR(v any) (string, error) {
+	var sb strings.Builder
+	if err := printR(&sb, v, nil, 0); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// FormatVarDump renders v byte-for-byte like PHP 8's var_dump; see FormatPrintR for the
+// supported values and the entry order.
+func FormatVarDump(v any) (string, error) {
+	var sb strings.Builder
+	if err := varDump(&sb, v, nil, 1); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// FormatVarExport renders v byte-for-byte like PHP 8's var_export (without a trailing
+// newline, like PHP); see FormatPrintR for the supported values and the entry order.
+func FormatVarExport(v any) (string, error) {
+	var sb strings.Builder
+	if err := varExport(&sb, v, nil, 1); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// printR follows zend_print_zval_r: indent is the indentation of v's "(" line.
+func printR(sb *strings.Builder, v any, path []string, indent int) error {
+	if entries, ok := containerEntries(v, len(path) == 0); ok {
+		pad := strings.Repeat(" ", indent)
+		sb.WriteString("Array\n" + pad + "(\n")
+		for _, e := range entries {
+			sb.WriteString(pad + "    [" + e.key + "] => ")
+			if err := printR(sb, e.value, append(path, e.key), indent+8); err != nil {
+				return err
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString(pad + ")\n")
+		return nil
+	}
+	typ, s, err := phpLeaf("print_r", path, v)
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case "NULL":
+	case "bool":
+		if s == "true" {
+			sb.WriteString("1")
+		}
+	case "float":
+		f, _ := strconv.ParseFloat(s, 64)
+		sb.WriteString(formatFloatPrecision(f, 14))
+	default:
+		sb.WriteString(s)
+	}
+	return nil
+}
+
+// varDump follows php_var_dump at nesting level (1 for the top).
+func varDump(sb *strings.Builder, v any, path []string, level int) error {
+	pad := strings.Repeat(" ", level-1)
+	if entries, ok := containerEntries(v, len(path) == 0); ok {
+		fmt.Fprintf(sb, "%sarray(%d) {\n", pad, len(entries))
+		for _, e := range entries {
+			sb.WriteString(strings.Repeat(" ", level+1))
+			if e.isInt {
+				sb.WriteString("[" + e.key + "]=>\n")
+			} else {
+				sb.WriteString(`["` + e.key + "\"]=>\n")
+			}
+			if err := varDump(sb, e.value, append(path, e.key), level+2); err != nil {
+				return err
+			}
+		}
+		sb.WriteString(pad + "}\n")
+		return nil
+	}
+	typ, s, err := phpLeaf("var_dump", path, v)
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case "NULL":
+		sb.WriteString(pad + "NULL\n")
+	case "float":
+		f, _ := strconv.ParseFloat(s, 64)
+		sb.WriteString(pad + "float(" + formatFloat(f) + ")\n")
+	case "string":
+		fmt.Fprintf(sb, "%sstring(%d) \"%s\"\n", pad, len(s), s)
+	default:
+		sb.WriteString(pad + typ + "(" + s + ")\n")
+	}
+	return nil
+}
+
+// varExport follows php_var_export_ex at nesting level (1 for the top).
+func varExport(sb *strings.Builder, v any, path []string, level int) error {
+	if entries, ok := containerEntries(v, len(path) == 0); ok {
+		if level > 1 {
+			sb.WriteString("\n" + strings.Repeat(" ", level-1))
+		}
+		sb.WriteString("array (\n")
+		for _, e := range entries {
+			sb.WriteString(strings.Repeat(" ", level+1))
+			if e.isInt {
+				sb.WriteString(e.key)
+			} else {
+				sb.WriteString(exportString(e.key))
+			}
+			sb.WriteString(" => ")
+			if err := varExport(sb, e.value, append(path, e.key), level+2); err != nil {
+				return err
+			}
+			sb.WriteString(",\n")
+		}
+		if level > 1 {
+			sb.WriteString(strings.Repeat(" ", level-1))
+		}
+		sb.WriteString(")")
+		return nil
+	}
+	typ, s, err := phpLeaf("var_export", path, v)
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case "float":
+		f, _ := strconv.ParseFloat(s, 64)
+		s = formatFloat(f)
+		if !math.IsInf(f, 0) && !math.IsNaN(f) && !strings.ContainsAny(s, ".eE") {
+			s += ".0" // var_export keeps floats recognizable
+		}
+		sb.WriteString(s)
+	case "string":
+		sb.WriteString(exportString(s))
+	default:
+		sb.WriteString(s)
+	}
+	return nil
+}
+
+// phpLeaf classifies a leaf as PHP sees it: typ is "NULL", "bool", "int", "float" or
+// "string"; s is "true"/"false" for bools, the decimal form for numbers, the string itself
+// otherwise. fn names the calling PHP function for the error message.
+func phpLeaf(fn string, path []string, v any) (typ, s string, err error) {
+	switch c := v.(type) {
+	case nil:
+		return "NULL", "NULL", nil
+	case bool:
+		return "bool", strconv.FormatBool(c), nil
+	case string:
+		return "string", c, nil
+	case Leaf:
+		return "string", c.Value, nil
+	case float32:
+		return "float", strconv.FormatFloat(float64(c), 'g', -1, 32), nil
+	case float64:
+		return "float", strconv.FormatFloat(c, 'g', -1, 64), nil
+	}
+	if s, ok := scalarString(v); ok {
+		return "int", s, nil
+	}
+	where := "value"
+	if len(path) > 0 {
+		where = pathString(path)
+	}
+	return "", "", fmt.Errorf("%s: %s: %w: %T", fn, where, ErrUnsupportedType, v)
+}
+
+// pathString renders the base and tokens of a value in bracket notation for error messages.
+// The encoders pass paths as slices they extend in place, so deep trees cost no copying.
+func pathString(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return keyPath(path[0], path[1:])
+}
+
+// exportString quotes s like var_export: backslash and quote escaped, NUL spelled out.
+func exportString(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+	return "'" + strings.ReplaceAll(s, "\x00", `' . "\0" . '`) + "'"
+}
+
+// formatFloatPrecision renders f like PHP's float to string conversion under the precision
+// ini setting (print_r, echo): prec significant digits, exponent form when the decimal
+// exponent is below -4 or at least prec.
+func formatFloatPrecision(f float64, prec int) string {
+	if f == 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return formatFloat(f)
+	}
+	e := strconv.FormatFloat(f, 'e', prec-1, 64) // -d.ddde±XX
+	mant, expStr, _ := strings.Cut(e, "e")
+	exp, _ := strconv.Atoi(expStr)
+	sign := ""
+	if strings.HasPrefix(mant, "-") {
+		sign, mant = "-", mant[1:]
+	}
+	digits := strings.TrimRight(strings.Replace(mant, ".", "", 1), "0")
+	switch {
+	case exp < -4 || exp >= prec:
+		frac := digits[1:]
+		if frac == "" {
+			frac = "0"
+		}
+		esign := "+"
+		if exp < 0 {
+			esign, exp = "-", -exp
+		}
+		return sign + digits[:1] + "." + frac + "E" + esign + strconv.Itoa(exp)
+	case exp < 0:
+		return sign + "0." + strings.Repeat("0", -exp-1) + digits
+	case len(digits) <= exp+1:
+		return sign + digits + strings.Repeat("0", exp+1-len(digits))
+	}
+	return sign + digits[:exp+1] + "." + digits[exp+1:]
+}
@@ -0,0 +1,148 @@
+package parsephp
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// formatInput covers int-like and string keys, nesting, a nil hole and quoting.
+var formatInput = map[string]any{
+	"a": "1",
+	"b": []any{"x", nil, "y"},
+	"c": map[string]any{"0": "z", "k": map[string]any{"01": "it's"}},
+	"5": "",
+}
+
+func TestFormatPrintR(t *testing.T) {
+	want := `Array
+(
+    [5] => 
+    [a] => 1
+    [b] => Array
+        (
+            [0] => x
+            [1] => 
+            [2] => y
+        )
+
+    [c] => Array
+        (
+            [0] => z
+            [k] => Array
+                (
+                    [01] => it's
+                )
+
+        )
+
+)
+`
+	got, err := FormatPrintR(formatInput)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatVarDump(t *testing.T) {
+	want := `array(4) {
+  [5]=>
+  string(0) ""
+  ["a"]=>
+  string(1) "1"
+  ["b"]=>
+  array(3) {
+    [0]=>
+    string(1) "x"
+    [1]=>
+    NULL
+    [2]=>
+    string(1) "y"
+  }
+  ["c"]=>
+  array(2) {
+    [0]=>
+    string(1) "z"
+    ["k"]=>
+    array(1) {
+      ["01"]=>
+      string(4) "it's"
+    }
+  }
+}
+`
+	got, err := FormatVarDump(formatInput)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatVarExport(t *testing.T) {
+	want := `array (
+  5 => '',
+  'a' => '1',
+  'b' => 
+  array (
+    0 => 'x',
+    1 => NULL,
+    2 => 'y',
+  ),
+  'c' => 
+  array (
+    0 => 'z',
+    'k' => 
+    array (
+      '01' => 'it\'s',
+    ),
+  ),
+)`
+	got, err := FormatVarExport(formatInput)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormat_Scalars(t *testing.T) {
+	tests := []struct {
+		v                    any
+		printR, dump, export string
+	}{
+		{nil, "", "NULL\n", "NULL"},
+		{true, "1", "bool(true)\n", "true"},
+		{false, "", "bool(false)\n", "false"},
+		{42, "42", "int(42)\n", "42"},
+		{1.0, "1", "float(1)\n", "1.0"},
+		{0.30000000000000004, "0.3", "float(0.30000000000000004)\n", "0.30000000000000004"},
+		{1e25, "1.0E+25", "float(1.0E+25)\n", "1.0E+25"},
+		{-0.00001234, "-1.234E-5", "float(-1.234E-5)\n", "-1.234E-5"},
+		{math.Inf(1), "INF", "float(INF)\n", "INF"},
+		{"a\\b\x00", "a\\b\x00", "string(4) \"a\\b\x00\"\n", `'a\\b' . "\0" . ''`},
+	}
+	for _, tt := range tests {
+		if got, _ := FormatPrintR(tt.v); got != tt.printR {
+			t.Fatalf("print_r(%v): got %q, want %q", tt.v, got, tt.printR)
+		}
+		if got, _ := FormatVarDump(tt.v); got != tt.dump {
+			t.Fatalf("var_dump(%v): got %q, want %q", tt.v, got, tt.dump)
+		}
+		if got, _ := FormatVarExport(tt.v); got != tt.export {
+			t.Fatalf("var_export(%v): got %q, want %q", tt.v, got, tt.export)
+		}
+	}
+}
+
+func TestFormat_UnsupportedType(t *testing.T) {
+	_, err := FormatVarDump(map[string]any{"a": []any{struct{}{}}})
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("got %v, want ErrUnsupportedType", err)
+	}
+}
@@ -0,0 +1,251 @@
+package parsephp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+// fuzzSeeds are queries from the parser and audit tests that exercise tokenizeKey,
+// lenientDecode and insert's scalar/slice/map conversions.
+var fuzzSeeds = []string{
+	"",
+	"   k   =   v   ",
+	";x=1;y=2&a=3",
+	"?x=1&y=2&",
+	"a=1&a[]=2&b=1&b[c]=2&d[][e]=f",
+	"a[1000]=x",
+	"a[5]=x&a[b]=y&a[]=z&a[01]=w",
+	"a[]=x&a=Y",
+	"a[]=x&a[0]=y&a[0][k]=z",
+	"a[b]=x&a[0]=y&a[]=z",
+	"a[b][c]=d&a[d]=c&a[=1",
+	"a[b][c]=d&a[d]]=c",
+	"a[b][c][d][e][f]=x",
+	"a[x]=1&a[y]=2&a[0]=3",
+	"bad=%ZZ&k%2=v%",
+	"user.name=x&a b=y",
+	"x]=1&[]=2&[x]=3",
+	"a[99999999999999999999]=x&a[-1]=y",
+	"k=%E4%B8%AD%E6%96%87&%FF%FE=%C3",
+}
+
+// addQuerySeeds seeds f with the round-trip and parser test queries.
+func addQuerySeeds(f *testing.F) {
+	for _, q := range roundTripQueries {
+		f.Add(q)
+	}
+	for _, q := range fuzzSeeds {
+		f.Add(q)
+	}
+	f.Add("a[999999999]=x&a[]=y&b[0]=1&b[5000]=2")
+	f.Add("a[1]=x&a[1027]=y&b[1027]=&b[00]=")
+}
+
+func FuzzParseStr(f *testing.F) {
+	addQuerySeeds(f)
+	strict := DefaultOptions
+	strict.StrictDecode = true
+	replace := DefaultOptions
+	replace.InvalidUTF8 = InvalidUTF8Replace
+	reject := DefaultOptions
+	reject.InvalidUTF8 = InvalidUTF8Error
+	f.Fuzz(func(t *testing.T, query string) {
+		for _, opts := range []Options{DefaultOptions, PHPOptions} {
+			got, err := ParseStrWithOptions(query, opts)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", query, err)
+			}
+			if err := checkResultTypes("", got); err != nil {
+				t.Fatalf("%q: %v", query, err)
+			}
+		}
+
+		if _, err := ParseStrWithOptions(query, strict); err != nil && !errors.Is(err, ErrInvalidPercent) {
+			t.Fatalf("%q: strict error %v does not wrap ErrInvalidPercent", query, err)
+		}
+
+		if got, _ := ParseStrWithOptions(query, replace); !validResultUTF8(got) {
+			t.Fatalf("%q: InvalidUTF8Replace left malformed UTF-8 in %#v", query, got)
+		}
+		if _, err := ParseStrWithOptions(query, reject); err != nil && !errors.Is(err, ErrInvalidUTF8) {
+			t.Fatalf("%q: InvalidUTF8Error error %v does not wrap ErrInvalidUTF8", query, err)
+		}
+	})
+}
+
+func FuzzHTTPBuildQuery(f *testing.F) {
+	addQuerySeeds(f)
+	f.Fuzz(func(t *testing.T, query string) {
+		first, _ := ParseStrWithOptions(query, DefaultOptions)
+		if hasPaddedIndex(first) {
+			// "00" indexes a slice as 0 but stays "00" in a map, so its spelling after a
+			// round-trip depends on which container HTTPBuildQuery's key order builds first.
+			return
+		}
+		built, err := HTTPBuildQuery(first, DefaultBuildOptions)
+		if err != nil {
+			t.Fatalf("%q: build error: %v", query, err)
+		}
+		second, err := ParseStrWithOptions(built, DefaultOptions)
+		if err != nil {
+			t.Fatalf("%q: reparse error: %v", built, err)
+		}
+		if !reflect.DeepEqual(first, second) {
+			t.Fatalf("%q -> %q: got %#v, want %#v", query, built, second, first)
+		}
+
+		// PHPOptions results are not stable under a second parse, as in PHP: "a[ " gives
+		// "a_ ", which mangles to "a__". They must still build.
+		php, _ := ParseStrWithOptions(query, PHPOptions)
+		if _, err := HTTPBuildQuery(php, DefaultBuildOptions); err != nil {
+			t.Fatalf("%q: PHPOptions build error: %v", query, err)
+		}
+	})
+}
+
+// hasPaddedIndex reports whether v has a map key of digits with leading zeros.
+func hasPaddedIndex(v any) bool {
+	switch c := v.(type) {
+	case map[string]any:
+		for k, e := range c {
+			if isNumeric(k) && len(k) > 1 && k[0] == '0' || hasPaddedIndex(e) {
+				return true
+			}
+		}
+	case []any:
+		for _, e := range c {
+			if hasPaddedIndex(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func FuzzSerialize(f *testing.F) {
+	addQuerySeeds(f)
+	f.Fuzz(func(t *testing.T, query string) {
+		parsed, _ := ParseStr(query)
+		s, err := Serialize(parsed)
+		if err != nil {
+			t.Fatalf("%q: serialize error: %v", query, err)
+		}
+		v, err := Unserialize(s)
+		if err != nil {
+			t.Fatalf("%q: unserialize %s: %v", query, s, err)
+		}
+		// map order is lost on unserialize, so compare from the first unserialized value on
+		s, err = Serialize(v)
+		if err != nil {
+			t.Fatalf("%q: reserialize error: %v", query, err)
+		}
+		w, err := Unserialize(s)
+		if err != nil {
+			t.Fatalf("%q: unserialize %s: %v", query, s, err)
+		}
+		if !reflect.DeepEqual(v, w) {
+			t.Fatalf("%q: got %#v, want %#v", query, w, v)
+		}
+	})
+}
+
+func FuzzUnserialize(f *testing.F) {
+	for _, s := range []string{
+		`a:2:{s:1:"a";a:1:{i:1;s:1:"x";}s:1:"b";N;}`,
+		`a:4:{i:0;b:1;i:1;i:-3;i:2;d:1.5E+25;s:1:"k";s:0:"";}`,
+		`a:1:{i:0;a:1:{i:0;a:0:{}}}`,
+		`O:8:"stdClass":0:{}`,
+		`s:3:"ab";`,
+		`d:NAN;`,
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, payload string) {
+		v, err := Unserialize(payload)
+		if err != nil {
+			var ue *UnserializeError
+			if !errors.As(err, &ue) {
+				t.Fatalf("%q: error %v is not an *UnserializeError", payload, err)
+			}
+			return
+		}
+		s, err := Serialize(v)
+		if err != nil {
+			t.Fatalf("%q: serialize error: %v", payload, err)
+		}
+		w, err := Unserialize(s)
+		if err != nil {
+			t.Fatalf("%q: unserialize %s: %v", payload, s, err)
+		}
+		if again, _ := Serialize(w); again != s {
+			t.Fatalf("%q: reserialized %s, want %s", payload, again, s)
+		}
+	})
+}
+
+func FuzzMarshalPHPJSON(f *testing.F) {
+	addQuerySeeds(f)
+	f.Fuzz(func(t *testing.T, query string) {
+		parsed, _ := ParseStr(query)
+		if _, err := MarshalPHPJSON(parsed, 0); err != nil && !errors.Is(err, ErrInvalidUTF8) {
+			t.Fatalf("%q: error %v does not wrap ErrInvalidUTF8", query, err)
+		}
+		for _, flags := range []JSONFlags{JSONInvalidUTF8Substitute, JSONInvalidUTF8Ignore | JSONPrettyPrint | JSONForceObject} {
+			out, err := MarshalPHPJSON(parsed, flags)
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", query, err)
+			}
+			if !json.Valid(out) {
+				t.Fatalf("%q: invalid JSON %s", query, out)
+			}
+		}
+	})
+}
+
+// checkResultTypes reports the first value under path that is not a string, nil, []any or map[string]any.
+func checkResultTypes(path string, v any) error {
+	switch c := v.(type) {
+	case nil, string:
+		return nil
+	case []any:
+		for i, elem := range c {
+			if err := checkResultTypes(fmt.Sprintf("%s[%d]", path, i), elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]any:
+		for k, elem := range c {
+			if err := checkResultTypes(path+"["+k+"]", elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: unexpected type %T", path, v)
+}
+
+// validResultUTF8 reports whether every key and string of a parse result is valid UTF-8.
+func validResultUTF8(v any) bool {
+	switch c := v.(type) {
+	case string:
+		return utf8.ValidString(c)
+	case []any:
+		for _, elem := range c {
+			if !validResultUTF8(elem) {
+				return false
+			}
+		}
+	case map[string]any:
+		for k, elem := range c {
+			if !utf8.ValidString(k) || !validResultUTF8(elem) {
+				return false
+			}
+		}
+	}
+	return true
+}
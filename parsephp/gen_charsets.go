@@ -0,0 +1,128 @@
+//go:build ignore
+
+// gen_charsets builds the charset tables from the unicode.org mapping files:
+//
+//	MAPPINGS/VENDORS/MICSFT/WINDOWS/CP1250.TXT, CP1251.TXT, CP1252.TXT, CP932.TXT, CP936.TXT, CP950.TXT
+//	MAPPINGS/ISO8859/8859-15.TXT
+//	MAPPINGS/VENDORS/MISC/KOI8-R.TXT
+//
+// Download them into one directory and run
+//
+//	go run gen_charsets.go -src DIR
+//
+// It writes charset_tables.go (single-byte charsets) and charsetdata/*.bin (double-byte
+// charsets: 256 single-byte entries, then 126 lead bytes 0x81-0xFE x 191 trail bytes
+// 0x40-0xFE, as big-endian uint16 code points, 0 meaning unmapped).
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var singleByte = []struct{ name, file string }{
+	{"windows1250", "CP1250.TXT"},
+	{"windows1251", "CP1251.TXT"},
+	{"windows1252", "CP1252.TXT"},
+	{"iso8859_15", "8859-15.TXT"},
+	{"koi8r", "KOI8-R.TXT"},
+}
+
+var doubleByte = []struct{ name, file string }{
+	{"cp932", "CP932.TXT"},
+	{"cp936", "CP936.TXT"},
+	{"cp950", "CP950.TXT"},
+}
+
+func main() {
+	src := flag.String("src", ".", "directory holding the unicode.org mapping files")
+	flag.Parse()
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen_charsets.go; DO NOT EDIT.\n\npackage parsephp\n")
+	for _, cs := range singleByte {
+		m := readMapping(filepath.Join(*src, cs.file))
+		fmt.Fprintf(&buf, "\n// %s maps bytes 0x80-0xFF of %s; 0xFFFD marks unmapped bytes.\nvar %s = [128]rune{", cs.name, cs.file, cs.name)
+		for b := 0x80; b <= 0xFF; b++ {
+			if b%8 == 0 {
+				buf.WriteString("\n")
+			}
+			r, ok := m[b]
+			if !ok {
+				r = 0xFFFD
+			}
+			fmt.Fprintf(&buf, "0x%04X, ", r)
+		}
+		buf.WriteString("\n}\n")
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("charset_tables.go", out, 0o644); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, cs := range doubleByte {
+		m := readMapping(filepath.Join(*src, cs.file))
+		table := make([]uint16, 256+126*191)
+		for code, r := range m {
+			if r > 0xFFFF {
+				log.Fatalf("%s: 0x%X maps outside the BMP", cs.file, code)
+			}
+			switch lead, trail := code>>8, code&0xFF; {
+			case code <= 0xFF:
+				table[code] = uint16(r)
+			case lead >= 0x81 && lead <= 0xFE && trail >= 0x40 && trail <= 0xFE:
+				table[256+(lead-0x81)*191+trail-0x40] = uint16(r)
+			default:
+				log.Fatalf("%s: 0x%X outside the table layout", cs.file, code)
+			}
+		}
+		var data bytes.Buffer
+		if err := binary.Write(&data, binary.BigEndian, table); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join("charsetdata", cs.name+".bin"), data.Bytes(), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// readMapping reads "0xXX[XX]<tab>0xUUUU<tab>#comment" lines; lines without a code point
+// (undefined bytes, DBCS lead byte markers) are skipped.
+func readMapping(path string) map[int]rune {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	m := make(map[int]rune)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		code, err1 := strconv.ParseInt(fields[0], 0, 32)
+		r, err2 := strconv.ParseInt(fields[1], 0, 32)
+		if err1 != nil || err2 != nil {
+			log.Fatalf("%s: bad line %q", path, sc.Text())
+		}
+		m[int(code)] = rune(r)
+	}
+	if err := sc.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return m
+}
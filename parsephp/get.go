@@ -0,0 +1,101 @@
+package parsephp
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Get returns the value at path in a ParseStr/ParseStrWithOptions result. path uses the
+// query's bracket syntax with decoded keys, e.g. "filter[price][0]", and is split with
+// the same tokenizer as query keys; the base and tokens are trimmed like parsed keys, as
+// in Set. Slice elements are addressed by index (nil holes do not exist, like unset PHP
+// keys); "[]" addresses nothing.
+func Get(result map[string]any, path string) (any, bool) {
+	base, tokens, ok := splitPath(path)
+	if !ok {
+		return nil, false
+	}
+	cur, ok := result[base]
+	for _, tok := range tokens {
+		if !ok {
+			return nil, false
+		}
+		switch c := cur.(type) {
+		case map[string]any:
+			cur, ok = c[tok]
+		case []any:
+			n, err := strconv.Atoi(tok)
+			if !isNumeric(tok) || err != nil || n >= len(c) {
+				return nil, false
+			}
+			cur, ok = c[n], true
+		default:
+			return nil, false
+		}
+	}
+	if !ok || cur == nil {
+		return nil, false
+	}
+	return cur, true
+}
+
+// splitPath tokenizes a Get/Set/Delete path into its base and bracket tokens, trimmed like
+// the keys of parsed pairs. ok is false when the base is empty.
+func splitPath(path string) (base string, tokens []string, ok bool) {
+	seq := tokenizeKey(path)
+	if len(seq) == 0 {
+		return "", nil, false
+	}
+	base = strings.TrimSpace(seq[0])
+	tokens = make([]string, len(seq)-1)
+	for i, tok := range seq[1:] {
+		tokens[i] = strings.TrimSpace(tok)
+	}
+	return base, tokens, base != ""
+}
+
+// GetString returns the string at path (the Value of a Leaf).
+//
+// The typed getters return a *FieldError carrying path: wrapping ErrNotFound when Get
+// finds nothing, otherwise explaining why the value does not convert. Conversions follow
+// Unmarshal, so an empty string is a zero int and bools accept "1"/"0", "true"/"false",
+// "on"/"off" and "yes"/"no".
+func GetString(result map[string]any, path string) (string, error) {
+	var s string
+	return s, getAs(result, path, &s)
+}
+
+// GetInt returns the integer at path.
+func GetInt(result map[string]any, path string) (int, error) {
+	var n int
+	return n, getAs(result, path, &n)
+}
+
+// GetBool returns the boolean at path.
+func GetBool(result map[string]any, path string) (bool, error) {
+	var b bool
+	return b, getAs(result, path, &b)
+}
+
+// GetStrings returns the list of strings at path, e.g. "tags" for tags[]=a&tags[]=b.
+// Holes become empty strings.
+func GetStrings(result map[string]any, path string) ([]string, error) {
+	var list []string
+	return list, getAs(result, path, &list)
+}
+
+// GetMap returns the array at path keyed by string; a list is keyed by its indices.
+func GetMap(result map[string]any, path string) (map[string]any, error) {
+	var m map[string]any
+	return m, getAs(result, path, &m)
+}
+
+// getAs stores the value at path into dst, a pointer, with Unmarshal's conversions.
+func getAs(result map[string]any, path string, dst any) error {
+	v, ok := Get(result, path)
+	if !ok {
+		return &FieldError{Path: path, Msg: "not found", Err: ErrNotFound}
+	}
+	return decodeValue(v, reflect.ValueOf(dst).Elem(), path)
+}
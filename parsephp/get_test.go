@@ -0,0 +1,87 @@
+package parsephp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	result, err := ParseStr("filter[price][]=10&filter[price][]=20&filter[brand]=acme&a[0]=x&a[2]=y&s=v")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cases := []struct {
+		path string
+		want any
+		ok   bool
+	}{
+		{"filter[price][0]", "10", true},
+		{"filter[price][01]", "20", true}, // read like the parser reads a[01]
+		{"filter[brand]", "acme", true},
+		{"filter[price]", []any{"10", "20"}, true},
+		{"a[2]", "y", true},
+		{"a[1]", nil, false}, // hole
+		{"a[3]", nil, false},
+		{"a[x]", nil, false},
+		{"a[]", nil, false},
+		{"s[0]", nil, false}, // below a string
+		{"missing", nil, false},
+		{"", nil, false},
+	}
+	for _, c := range cases {
+		got, ok := Get(result, c.path)
+		if ok != c.ok || !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%s: got %#v, %v, want %#v, %v", c.path, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestGet_Typed(t *testing.T) {
+	result, err := ParseStr("page=3&debug=on&bad=x&big=99999999999999999999&tags[]=a&tags[2]=c&q[k]=v&q[n][]=1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n, err := GetInt(result, "page"); n != 3 || err != nil {
+		t.Fatalf("page: got %d, %v", n, err)
+	}
+	if b, err := GetBool(result, "debug"); !b || err != nil {
+		t.Fatalf("debug: got %v, %v", b, err)
+	}
+	if s, err := GetString(result, "q[k]"); s != "v" || err != nil {
+		t.Fatalf("q[k]: got %q, %v", s, err)
+	}
+	if list, err := GetStrings(result, "tags"); !reflect.DeepEqual(list, []string{"a", "", "c"}) || err != nil {
+		t.Fatalf("tags: got %q, %v", list, err)
+	}
+	if m, err := GetMap(result, "q"); !reflect.DeepEqual(m, map[string]any{"k": "v", "n": []any{"1"}}) || err != nil {
+		t.Fatalf("q: got %#v, %v", m, err)
+	}
+	if m, err := GetMap(result, "tags"); !reflect.DeepEqual(m, map[string]any{"0": "a", "2": "c"}) || err != nil {
+		t.Fatalf("tags as map: got %#v, %v", m, err)
+	}
+
+	failures := []struct {
+		name string
+		get  func() error
+		msg  string
+	}{
+		{"missing", func() error { _, err := GetInt(result, "nope[0]"); return err }, "nope[0]: not found"},
+		{"invalid int", func() error { _, err := GetInt(result, "bad"); return err }, "bad: invalid integer"},
+		{"out of range", func() error { _, err := GetInt(result, "big"); return err }, "big: integer out of range"},
+		{"invalid bool", func() error { _, err := GetBool(result, "bad"); return err }, "bad: invalid boolean"},
+		{"array as string", func() error { _, err := GetString(result, "q"); return err }, "q: expected string, got array"},
+		{"string as list", func() error { _, err := GetStrings(result, "page"); return err }, "page: expected list, got string"},
+		{"nested list", func() error { _, err := GetStrings(result, "q"); return err }, "q: expected list, got array"},
+	}
+	for _, f := range failures {
+		err := f.get()
+		var fe *FieldError
+		if !errors.As(err, &fe) || err.Error() != f.msg {
+			t.Fatalf("%s: got %v, want %q", f.name, err, f.msg)
+		}
+		if notFound := errors.Is(err, ErrNotFound); notFound != (f.name == "missing") {
+			t.Fatalf("%s: errors.Is(ErrNotFound) = %v", f.name, notFound)
+		}
+	}
+}
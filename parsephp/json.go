@@ -0,0 +1,194 @@
+package parsephp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONFlags selects json_encode options for MarshalPHPJSON; the values are PHP's.
+type JSONFlags int
+
+const (
+	JSONHexTag                   JSONFlags = 1       // JSON_HEX_TAG: < and > as \u003C, \u003E
+	JSONHexAmp                   JSONFlags = 2       // JSON_HEX_AMP: & as \u0026
+	JSONHexApos                  JSONFlags = 4       // JSON_HEX_APOS: ' as \u0027
+	JSONHexQuot                  JSONFlags = 8       // JSON_HEX_QUOT: " as \u0022
+	JSONForceObject              JSONFlags = 16      // JSON_FORCE_OBJECT: lists as objects too
+	JSONUnescapedSlashes         JSONFlags = 64      // JSON_UNESCAPED_SLASHES: / not escaped
+	JSONPrettyPrint              JSONFlags = 128     // JSON_PRETTY_PRINT: 4-space indentation
+	JSONUnescapedUnicode         JSONFlags = 256     // JSON_UNESCAPED_UNICODE: UTF-8 as is
+	JSONPreserveZeroFraction     JSONFlags = 1024    // JSON_PRESERVE_ZERO_FRACTION: 1.0 instead of 1
+	JSONUnescapedLineTerminators JSONFlags = 2048    // JSON_UNESCAPED_LINE_TERMINATORS: U+2028/2029 as is
+	JSONInvalidUTF8Ignore        JSONFlags = 1 << 20 // JSON_INVALID_UTF8_IGNORE: drop invalid bytes
+	JSONInvalidUTF8Substitute    JSONFlags = 1 << 21 // JSON_INVALID_UTF8_SUBSTITUTE: invalid bytes as U+FFFD
+)
+
+// MarshalPHPJSON encodes v like PHP's json_encode(v, flags). v is a ParseStr-style tree
+// (see FormatPrintR). As in PHP, an array is a JSON list only when its keys are exactly
+// 0..n-1 in order, so a map that ensureMap made from a slice is an object again and nil
+// slice holes, which are skipped, make the rest an object (a[1]=x gives {"a":{"1":"x"}}); entries
+// come in the deterministic order of HTTPBuildQuery. Maps and slices do not keep PHP's
+// key order, so a[1]=x&a[0]=y encodes as a list where PHP gives {"a":{"1":"x","0":"y"}};
+// pass the *Array of ParseStrOrdered to get PHP's output. Unsupported leaves yield an error
+// wrapping ErrUnsupportedType, invalid UTF-8 (without JSONInvalidUTF8Ignore/Substitute)
+// one wrapping ErrInvalidUTF8; INF and NAN cannot be encoded either.
+func MarshalPHPJSON(v any, flags JSONFlags) ([]byte, error) {
+	var sb strings.Builder
+	if err := encodeJSON(&sb, v, nil, flags, 0); err != nil {
+		return nil, err
+	}
+	return []byte(sb.String()), nil
+}
+
+func encodeJSON(sb *strings.Builder, v any, path []string, flags JSONFlags, depth int) error {
+	if entries, ok := containerEntries(v, len(path) == 0); ok {
+		entries = skipHoles(entries)
+		isList := flags&JSONForceObject == 0
+		for i, e := range entries {
+			if !isList {
+				break
+			}
+			isList = e.isInt && e.key == strconv.Itoa(i)
+		}
+		open, close := "{", "}"
+		if isList {
+			open, close = "[", "]"
+		}
+		sb.WriteString(open)
+		if len(entries) == 0 {
+			sb.WriteString(close)
+			return nil
+		}
+		pretty := flags&JSONPrettyPrint != 0
+		for i, e := range entries {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			if pretty {
+				sb.WriteString("\n" + strings.Repeat("    ", depth+1))
+			}
+			elemPath := append(path, e.key)
+			if !isList {
+				if err := encodeJSONString(sb, e.key, elemPath, flags); err != nil {
+					return err
+				}
+				sb.WriteString(":")
+				if pretty {
+					sb.WriteString(" ")
+				}
+			}
+			if err := encodeJSON(sb, e.value, elemPath, flags, depth+1); err != nil {
+				return err
+			}
+		}
+		if pretty {
+			sb.WriteString("\n" + strings.Repeat("    ", depth))
+		}
+		sb.WriteString(close)
+		return nil
+	}
+	typ, s, err := phpLeaf("json_encode", path, v)
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case "NULL":
+		sb.WriteString("null")
+	case "bool", "int":
+		sb.WriteString(s)
+	case "float":
+		f, _ := strconv.ParseFloat(s, 64)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Errorf("json_encode: %s: Inf and NaN cannot be JSON encoded", pathString(path))
+		}
+		s = strings.Replace(formatFloat(f), "E", "e", 1)
+		if flags&JSONPreserveZeroFraction != 0 && !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		sb.WriteString(s)
+	default:
+		return encodeJSONString(sb, s, path, flags)
+	}
+	return nil
+}
+
+// encodeJSONString writes s as a JSON string following php_json_escape_string.
+func encodeJSONString(sb *strings.Builder, s string, path []string, flags JSONFlags) error {
+	const hexDigits = "0123456789abcdef"
+	sb.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			switch {
+			case flags&JSONInvalidUTF8Ignore != 0:
+				i++
+				continue
+			case flags&JSONInvalidUTF8Substitute != 0:
+				r = utf8.RuneError
+			default:
+				return fmt.Errorf("json_encode: %s: %w at byte %d", pathString(path), ErrInvalidUTF8, i)
+			}
+		}
+		i += size
+		switch {
+		case r == '"':
+			if flags&JSONHexQuot != 0 {
+				sb.WriteString(`\u0022`)
+			} else {
+				sb.WriteString(`\"`)
+			}
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '/':
+			if flags&JSONUnescapedSlashes != 0 {
+				sb.WriteByte('/')
+			} else {
+				sb.WriteString(`\/`)
+			}
+		case r == '\b':
+			sb.WriteString(`\b`)
+		case r == '\f':
+			sb.WriteString(`\f`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '<' && flags&JSONHexTag != 0:
+			sb.WriteString(`\u003C`)
+		case r == '>' && flags&JSONHexTag != 0:
+			sb.WriteString(`\u003E`)
+		case r == '&' && flags&JSONHexAmp != 0:
+			sb.WriteString(`\u0026`)
+		case r == '\'' && flags&JSONHexApos != 0:
+			sb.WriteString(`\u0027`)
+		case r < 0x20,
+			r >= utf8.RuneSelf && flags&JSONUnescapedUnicode == 0,
+			(r == 0x2028 || r == 0x2029) && flags&JSONUnescapedLineTerminators == 0:
+			for _, u := range utf16Units(r) {
+				sb.WriteString(`\u`)
+				sb.WriteByte(hexDigits[u>>12])
+				sb.WriteByte(hexDigits[u>>8&0xF])
+				sb.WriteByte(hexDigits[u>>4&0xF])
+				sb.WriteByte(hexDigits[u&0xF])
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return nil
+}
+
+// utf16Units returns the UTF-16 code units of r (a surrogate pair above U+FFFF).
+func utf16Units(r rune) []uint16 {
+	if r < 0x10000 {
+		return []uint16{uint16(r)}
+	}
+	r -= 0x10000
+	return []uint16{uint16(0xD800 + r>>10), uint16(0xDC00 + r&0x3FF)}
+}
@@ -0,0 +1,105 @@
+package parsephp
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMarshalPHPJSON_ListsAndObjects(t *testing.T) {
+	tests := []struct {
+		query string
+		flags JSONFlags
+		want  string
+	}{
+		{"a[]=1&a[]=2", 0, `{"a":["1","2"]}`},
+		// nil holes are not keys, so the remaining keys decide list vs. object
+		{"a[0]=x&a[2]=y", 0, `{"a":{"0":"x","2":"y"}}`},
+		// ensureMap turned the slice into a map: json_encode shows an object
+		{"a[]=x&a[k]=y", 0, `{"a":{"0":"x","k":"y"}}`},
+		{"a[1]=x", 0, `{"a":{"1":"x"}}`},
+		{"a[]=1", JSONForceObject, `{"a":{"0":"1"}}`},
+		{"u=/p/é", 0, `{"u":"\/p\/\u00e9"}`},
+		{"u=/p/é", JSONUnescapedSlashes | JSONUnescapedUnicode, `{"u":"/p/é"}`},
+		{"t=<a href='x'>%22&amp", JSONHexTag | JSONHexApos | JSONHexQuot | JSONHexAmp, `{"amp":"","t":"\u003Ca href=\u0027x\u0027\u003E\u0022"}`},
+		{"e=%F0%9F%98%80%E2%80%A8x", JSONUnescapedUnicode, "{\"e\":\"\U0001F600\\u2028x\"}"},
+		{"e=%F0%9F%98%80", 0, `{"e":"\ud83d\ude00"}`},
+	}
+	for _, tt := range tests {
+		v, _ := ParseStr(tt.query)
+		got, err := MarshalPHPJSON(v, tt.flags)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.query, err)
+		}
+		if string(got) != tt.want {
+			t.Fatalf("%q: got %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+// A map[string]any or []any cannot hold PHP's key order: a[1]=x&a[0]=y is a list here,
+// while PHP keeps the keys in order 1, 0 and encodes an object. *Array keeps the order.
+func TestMarshalPHPJSON_KeyOrderDifference(t *testing.T) {
+	const query = "a[1]=x&a[0]=y"
+	v, _ := ParseStr(query)
+	got, err := MarshalPHPJSON(v, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"a":["y","x"]}`; string(got) != want {
+		t.Fatalf("map tree: got %s, want %s (known difference from PHP)", got, want)
+	}
+
+	ordered, err := ParseStrOrdered(query)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err = MarshalPHPJSON(ordered, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"a":{"1":"x","0":"y"}}`; string(got) != want {
+		t.Fatalf("*Array: got %s, want PHP's %s", got, want)
+	}
+}
+
+func TestMarshalPHPJSON_PrettyPrintAndScalars(t *testing.T) {
+	v := map[string]any{"a": []any{1, 1.0, 1e25, true}, "b": map[string]any{}, "c": []any{}}
+	got, err := MarshalPHPJSON(v, JSONPrettyPrint|JSONPreserveZeroFraction)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{
+    "a": [
+        1,
+        1.0,
+        1.0e+25,
+        true
+    ],
+    "b": [],
+    "c": []
+}`
+	if string(got) != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMarshalPHPJSON_Errors(t *testing.T) {
+	if _, err := MarshalPHPJSON(map[string]any{"a": "\xff"}, 0); !errors.Is(err, ErrInvalidUTF8) {
+		t.Fatalf("got %v, want ErrInvalidUTF8", err)
+	}
+	got, err := MarshalPHPJSON(map[string]any{"a": "x\xffy"}, JSONInvalidUTF8Substitute)
+	if err != nil || string(got) != `{"a":"x\ufffdy"}` {
+		t.Fatalf("substitute: got %s, %v", got, err)
+	}
+	got, err = MarshalPHPJSON(map[string]any{"a": "x\xffy"}, JSONInvalidUTF8Ignore)
+	if err != nil || string(got) != `{"a":"xy"}` {
+		t.Fatalf("ignore: got %s, %v", got, err)
+	}
+	if _, err := MarshalPHPJSON([]any{math.NaN()}, 0); err == nil {
+		t.Fatalf("NaN: got no error")
+	}
+	if _, err := MarshalPHPJSON([]any{struct{}{}}, 0); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("got %v, want ErrUnsupportedType", err)
+	}
+}
@@ -0,0 +1,17 @@
+package parsephp
+
+// Leaf is a leaf value parsed with Options.RawLeaves: the decoded value together with
+// the key and value exactly as they appeared in the input, e.g. for pair "a[b%20c]=x+y":
+// Leaf{RawKey: "a[b%20c]", Raw: "x+y", Value: "x y"}.
+//
+// HTTPBuildQuery, the PHP formatters, MarshalPHPJSON, Serialize and Unmarshal treat a
+// Leaf as its Value; Unmarshal also fills fields of type Leaf.
+type Leaf struct {
+	RawKey string // raw key of the pair, brackets included
+	Raw    string // raw value of the pair, "" when the pair has no '='
+	Value  string // decoded value, as ParseStrWithOptions returns it without RawLeaves
+}
+
+func (l Leaf) String() string {
+	return l.Value
+}
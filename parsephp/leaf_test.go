@@ -0,0 +1,64 @@
+package parsephp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRawLeaves(t *testing.T) {
+	opts := DefaultOptions
+	opts.RawLeaves = true
+	got, err := ParseStrWithOptions("sig=abc%2bdef&a=1&a[]=x+y&m[k%20]=%7E&flag", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{
+		"sig":  Leaf{RawKey: "sig", Raw: "abc%2bdef", Value: "abc+def"},
+		"a":    []any{Leaf{RawKey: "a", Raw: "1", Value: "1"}, Leaf{RawKey: "a[]", Raw: "x+y", Value: "x y"}},
+		"m":    map[string]any{"k": Leaf{RawKey: "m[k%20]", Raw: "%7E", Value: "~"}},
+		"flag": Leaf{RawKey: "flag", Value: ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+
+	// the default output is unchanged, and encoders see a Leaf as its Value
+	plain, _ := ParseStr("sig=abc%2bdef&a=1&a[]=x+y&m[k%20]=%7E&flag")
+	for name, enc := range map[string]func(any) (string, error){
+		"http_build_query": func(v any) (string, error) { return HTTPBuildQuery(v, DefaultBuildOptions) },
+		"print_r":          FormatPrintR,
+		"serialize":        Serialize,
+	} {
+		a, errA := enc(plain)
+		b, errB := enc(got)
+		if errA != nil || errB != nil || a != b {
+			t.Fatalf("%s: got %q (%v), want %q (%v)", name, b, errB, a, errA)
+		}
+	}
+}
+
+func TestRawLeaves_OrderedAndUnmarshal(t *testing.T) {
+	opts := DefaultOptions
+	opts.RawLeaves = true
+	arr, err := ParseStrOrderedWithOptions("a=1&a[]=2", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	inner, _ := arr.Get(StringKey("a"))
+	first, _ := inner.(*Array).Get(IntKey(0))
+	if first != (Leaf{RawKey: "a", Raw: "1", Value: "1"}) {
+		t.Fatalf("ordered: got %#v", first)
+	}
+
+	var cb struct {
+		Amount int    `php:"amount"`
+		Sig    Leaf   `php:"sig"`
+		Note   string `php:"note"`
+	}
+	if err := Unmarshal("amount=10&sig=a%2Fb&note=hi+there", &cb, opts); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if cb.Amount != 10 || cb.Sig != (Leaf{RawKey: "sig", Raw: "a%2Fb", Value: "a/b"}) || cb.Note != "hi there" {
+		t.Fatalf("unmarshal: got %+v", cb)
+	}
+}
@@ -0,0 +1,93 @@
+package parsephp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLimits_DropMode(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		opts Options
+		out  map[string]any
+	}{
+		{"max_input_vars_stops_reading", "a=1&&b=2&c=3&d=4", Options{MaxInputVars: 2},
+			map[string]any{"a": "1", "b": "2"}},
+		{"nesting_removes_whole_var", "a[x]=1&a[b][c][d]=2&a[y]=3&z[b][c]=4", Options{MaxNestingLevel: 2},
+			map[string]any{"a": map[string]any{"y": "3"}, "z": map[string]any{"b": map[string]any{"c": "4"}}}},
+		{"max_index", "a[999999999]=x&a[99999999999999999999]=y&a[10]=z&b[3]=w", Options{MaxIndex: 10},
+			map[string]any{"a": []any{nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "z"}, "b": []any{nil, nil, nil, "w"}}},
+		{"key_and_value_length", "long_key=1&k=long_value&k2=%41%41", Options{MaxKeyLength: 4, MaxValueLength: 6},
+			map[string]any{"k2": "AA"}},
+		{"total_bytes", "a=1&b=2", Options{MaxTotalBytes: 6}, map[string]any{}},
+	}
+	for _, c := range cases {
+		got, err := ParseStrWithOptions(c.in, c.opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(got, c.out) {
+			t.Fatalf("%s: got %#v, want %#v", c.name, got, c.out)
+		}
+	}
+}
+
+func TestLimits_StrictMode(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		opts Options
+		want LimitError
+	}{
+		{"vars", "?a=1&b=2&c=3", Options{MaxInputVars: 2, StrictLimits: true},
+			LimitError{Limit: "MaxInputVars", Max: 2, Offset: 9, Pair: "c=3"}},
+		{"nesting", "a[b][c]=1", Options{MaxNestingLevel: 1, StrictLimits: true},
+			LimitError{Limit: "MaxNestingLevel", Max: 1, Offset: 0, Pair: "a[b][c]=1"}},
+		{"index", "x=1;a[100]=1", Options{MaxIndex: 99, StrictLimits: true},
+			LimitError{Limit: "MaxIndex", Max: 99, Offset: 4, Pair: "a[100]=1"}},
+		{"total", "a=1&b=2", Options{MaxTotalBytes: 3, StrictLimits: true},
+			LimitError{Limit: "MaxTotalBytes", Max: 3, Offset: 3}},
+	}
+	for _, c := range cases {
+		_, err := ParseStrWithOptions(c.in, c.opts)
+		var le *LimitError
+		if !errors.As(err, &le) {
+			t.Fatalf("%s: got %v, want *LimitError", c.name, err)
+		}
+		if *le != c.want {
+			t.Fatalf("%s: got %#v, want %#v", c.name, *le, c.want)
+		}
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("%s: error does not match ErrLimitExceeded", c.name)
+		}
+	}
+}
+
+func TestLimits_OrderedRemovesOverNestedVar(t *testing.T) {
+	got, err := ParseStrOrderedWithOptions("a[x]=1&b=2&a[b][c]=3", Options{MaxNestingLevel: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(keysOf(got), want) {
+		t.Fatalf("got keys %#v, want %#v", keysOf(got), want)
+	}
+}
+
+func TestFarIndexTurnsSliceIntoMap(t *testing.T) {
+	got, err := ParseStr("a[999999999]=x&a[]=y&b[0]=1&b[1024]=2&c[0]=1&c[1026]=2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := make([]any, 1025)
+	b[0], b[1024] = "1", "2"
+	want := map[string]any{
+		"a": map[string]any{"999999999": "x", "1000000000": "y"},
+		"b": b,
+		"c": map[string]any{"0": "1", "1026": "2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
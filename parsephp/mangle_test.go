@@ -0,0 +1,54 @@
+package parsephp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMangleNames_BaseSegment(t *testing.T) {
+	cases := []struct {
+		in  string
+		out map[string]any
+	}{
+		{"user.name=x", map[string]any{"user_name": "x"}},
+		{"user%2Ename=x&a+b=y", map[string]any{"user_name": "x", "a_b": "y"}},
+		{"+lead=1", map[string]any{"lead": "1"}},
+		{"k =v", map[string]any{"k_": "v"}},
+		{"a.b[c.d]=1&a.b[e f]=2", map[string]any{"a_b": map[string]any{"c.d": "1", "e f": "2"}}},
+		{"a.b[c=1", map[string]any{"a_b_c": "1"}},
+		{"a[b.c=1", map[string]any{"a_b.c": "1"}},
+	}
+	opts := DefaultOptions
+	opts.MangleNames = true
+	for _, c := range cases {
+		got, err := ParseStrWithOptions(c.in, opts)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", c.in, err)
+		}
+		if !reflect.DeepEqual(got, c.out) {
+			t.Fatalf("%q: got %#v, want %#v", c.in, got, c.out)
+		}
+	}
+}
+
+func TestMangleNames_OffByDefault(t *testing.T) {
+	got, err := ParseStr("user.name=x&a b=y")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"user.name": "x", "a b": "y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestPHPOptions_Preset(t *testing.T) {
+	got, err := ParseStrWithOptions("user.name=x;y=1&z=2", PHPOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"user_name": "x;y=1", "z": "2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,182 @@
+package parsephp
+
+import "fmt"
+
+// PHP array operations on parse results. Arguments are ParseStr trees (map[string]any,
+// []any, nested) or *Array; nil slice holes are not keys. The result is a new tree that
+// shares nothing with the arguments: a *Array when every argument is one, otherwise a
+// []any for arrays whose keys are 0..n-1 in order and a map[string]any for the others.
+//
+// Keys follow PHP: "7" and 7 are the same integer key, "07" is a string key. A Go map
+// has no order, so its entries are taken in HTTPBuildQuery's order (integer prefix
+// 0, 1, ..., string keys sorted, remaining integer keys ascending); pass *Array values
+// (ParseStrOrdered) to renumber in the exact order of the query.
+
+// ArrayUnion is PHP's a + b: every key of a, then the keys of b that a lacks. Nothing is
+// renumbered and nested arrays are not merged.
+func ArrayUnion(a, b any) (any, error) {
+	return phpArrayOp("array union", []any{a, b}, func(out, src *Array) {
+		src.Range(func(k Key, v any) bool {
+			if _, ok := out.Get(k); !ok {
+				out.Set(k, v)
+			}
+			return true
+		})
+	})
+}
+
+// ArrayMerge is PHP's array_merge: integer keys are renumbered from 0 in order, and a
+// later string key overwrites an earlier one.
+func ArrayMerge(arrays ...any) (any, error) {
+	return phpArrayOp("array_merge", arrays, mergeInto)
+}
+
+// ArrayMergeRecursive is PHP's array_merge_recursive: like ArrayMerge, except that a
+// string key present on both sides collects both values in an array: arrays are merged
+// recursively, and a scalar becomes the first element or is appended.
+func ArrayMergeRecursive(arrays ...any) (any, error) {
+	return phpArrayOp("array_merge_recursive", arrays, mergeRecursiveInto)
+}
+
+// ArrayReplace is PHP's array_replace: later values overwrite earlier ones under the same
+// key, integer keys included; nothing is renumbered.
+func ArrayReplace(arrays ...any) (any, error) {
+	return phpArrayOp("array_replace", arrays, func(out, src *Array) {
+		src.Range(func(k Key, v any) bool {
+			out.Set(k, v)
+			return true
+		})
+	})
+}
+
+// ArrayReplaceRecursive is PHP's array_replace_recursive: like ArrayReplace, except that
+// two arrays under the same key are replaced recursively.
+func ArrayReplaceRecursive(arrays ...any) (any, error) {
+	return phpArrayOp("array_replace_recursive", arrays, replaceRecursiveInto)
+}
+
+// phpArrayOp converts arrays to fresh *Array trees, folds them into an empty array with
+// apply and converts the result back to the shape of the arguments.
+func phpArrayOp(fn string, arrays []any, apply func(out, src *Array)) (any, error) {
+	out := NewArray()
+	ordered := true
+	for i, v := range arrays {
+		src, ok := toArray(v)
+		if !ok {
+			return nil, fmt.Errorf("%s: argument #%d: %w: %T", fn, i+1, ErrUnsupportedType, v)
+		}
+		if _, isArray := v.(*Array); !isArray {
+			ordered = false
+		}
+		apply(out, src)
+	}
+	if ordered && len(arrays) > 0 {
+		return out, nil
+	}
+	return fromArray(out), nil
+}
+
+func mergeInto(out, src *Array) {
+	src.Range(func(k Key, v any) bool {
+		if k.IsInt() {
+			out.Append(v)
+		} else {
+			out.Set(k, v)
+		}
+		return true
+	})
+}
+
+// mergeRecursiveInto follows php_array_merge_recursive.
+func mergeRecursiveInto(out, src *Array) {
+	src.Range(func(k Key, v any) bool {
+		if k.IsInt() {
+			out.Append(v)
+			return true
+		}
+		existing, ok := out.Get(k)
+		if !ok {
+			out.Set(k, v)
+			return true
+		}
+		dst, isArray := existing.(*Array)
+		if !isArray {
+			dst = NewArray()
+			dst.Append(existing)
+		}
+		if sub, isArray := v.(*Array); isArray {
+			mergeRecursiveInto(dst, sub)
+		} else {
+			dst.Append(v)
+		}
+		out.Set(k, dst)
+		return true
+	})
+}
+
+// replaceRecursiveInto follows php_array_replace_recursive.
+func replaceRecursiveInto(out, src *Array) {
+	src.Range(func(k Key, v any) bool {
+		existing, _ := out.Get(k)
+		dst, dstArray := existing.(*Array)
+		sub, srcArray := v.(*Array)
+		if dstArray && srcArray {
+			replaceRecursiveInto(dst, sub)
+		} else {
+			out.Set(k, v)
+		}
+		return true
+	})
+}
+
+// toArray deep-copies a container into *Array values, in containerEntries order.
+func toArray(v any) (*Array, bool) {
+	entries, ok := containerEntries(v, false)
+	if !ok {
+		return nil, false
+	}
+	out := NewArray()
+	for _, e := range entries {
+		if e.value == nil {
+			continue
+		}
+		value := e.value
+		if sub, ok := toArray(e.value); ok {
+			value = sub
+		}
+		out.Set(StringKey(e.key), value)
+	}
+	return out, true
+}
+
+// fromArray converts an *Array tree to []any (keys 0..n-1 in order) and map[string]any.
+func fromArray(a *Array) any {
+	isList := true
+	i := 0
+	a.Range(func(k Key, _ any) bool {
+		isList = k.IsInt() && k.Int() == i
+		i++
+		return isList
+	})
+	if isList {
+		out := make([]any, 0, a.Len())
+		a.Range(func(_ Key, v any) bool {
+			out = append(out, fromValue(v))
+			return true
+		})
+		return out
+	}
+	out := make(map[string]any, a.Len())
+	a.Range(func(k Key, v any) bool {
+		out[k.String()] = fromValue(v)
+		return true
+	})
+	return out
+}
+
+func fromValue(v any) any {
+	if sub, ok := v.(*Array); ok {
+		return fromArray(sub)
+	}
+	return v
+}
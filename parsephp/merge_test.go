@@ -0,0 +1,108 @@
+package parsephp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func mustParse(t *testing.T, query string) map[string]any {
+	t.Helper()
+	m, err := ParseStr(query)
+	if err != nil {
+		t.Fatalf("%q: unexpected error: %v", query, err)
+	}
+	return m
+}
+
+func TestArrayOps(t *testing.T) {
+	cases := []struct {
+		name string
+		op   func(...any) (any, error)
+		args []string
+		want any
+	}{
+		{"union keeps left", func(a ...any) (any, error) { return ArrayUnion(a[0], a[1]) },
+			[]string{"page=2&0=x", "page=1&per_page=20&0=y&1=z"},
+			map[string]any{"page": "2", "per_page": "20", "0": "x", "1": "z"}},
+		{"merge renumbers", ArrayMerge,
+			[]string{"5=x&k=y", "0=z", "k=w"},
+			map[string]any{"0": "x", "1": "z", "k": "w"}},
+		{"merge lists", ArrayMerge,
+			[]string{"0=a&1=b", "0=c"},
+			[]any{"a", "b", "c"}},
+		{"merge is not recursive", ArrayMerge,
+			[]string{"f[a]=1&f[b]=2", "f[a]=3"},
+			map[string]any{"f": map[string]any{"a": "3"}}},
+		{"merge_recursive", ArrayMergeRecursive,
+			[]string{"a=1&l[]=x&n[]=1", "a=2&l[]=y&m[k]=v&n[]=2", "a[k]=3&m[k]=w"},
+			map[string]any{
+				"a": map[string]any{"0": "1", "1": "2", "k": "3"},
+				"l": []any{"x", "y"},
+				"m": map[string]any{"k": []any{"v", "w"}},
+				"n": []any{"1", "2"},
+			}},
+		{"merge_recursive appends scalar to array", ArrayMergeRecursive,
+			[]string{"a[x]=1", "a=2"},
+			map[string]any{"a": map[string]any{"x": "1", "0": "2"}}},
+		{"replace keeps integer keys", ArrayReplace,
+			[]string{"0=a&1=b", "1=c&3=d"},
+			map[string]any{"0": "a", "1": "c", "3": "d"}},
+		{"replace_recursive", ArrayReplaceRecursive,
+			[]string{"a[]=x&a[]=y&b=1&c[k]=1", "a[1]=z&b[]=q&c=2"},
+			map[string]any{"a": []any{"x", "z"}, "b": []any{"q"}, "c": "2"}},
+		{"holes are not keys", ArrayReplace,
+			[]string{"l[0]=a&l[2]=c", "l2[0]=a&l2[1]=b"},
+			map[string]any{"l": map[string]any{"0": "a", "2": "c"}, "l2": []any{"a", "b"}}},
+	}
+	for _, c := range cases {
+		args := make([]any, len(c.args))
+		for i, q := range c.args {
+			args[i] = mustParse(t, q)
+		}
+		got, err := c.op(args...)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%s: got %#v, want %#v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestArrayOps_Ordered(t *testing.T) {
+	a, _ := ParseStrOrdered("k=y&5=x")
+	b, _ := ParseStrOrdered("0=z&k=w")
+	got, err := ArrayMerge(a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	arr, ok := got.(*Array)
+	if !ok {
+		t.Fatalf("got %T, want *Array", got)
+	}
+	// PHP: ['k' => 'w', 0 => 'x', 1 => 'z']
+	want := []Key{StringKey("k"), IntKey(0), IntKey(1)}
+	if !reflect.DeepEqual(arr.Keys(), want) {
+		t.Fatalf("keys: got %v, want %v", arr.Keys(), want)
+	}
+	if v, _ := arr.Get(StringKey("k")); v != "w" {
+		t.Fatalf("k: got %#v", v)
+	}
+}
+
+func TestArrayOps_NoSharingAndErrors(t *testing.T) {
+	a := mustParse(t, "f[a][]=1")
+	got, err := ArrayReplaceRecursive(a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got.(map[string]any)["f"].(map[string]any)["a"].([]any)[0] = "changed"
+	if a["f"].(map[string]any)["a"].([]any)[0] != "1" {
+		t.Fatalf("result shares storage with its argument")
+	}
+
+	if _, err := ArrayMerge(a, "x"); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("got %v, want ErrUnsupportedType", err)
+	}
+}
@@ -0,0 +1,282 @@
+package parsephp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Upload error codes stored in UploadedFile.Error, equal to PHP's UPLOAD_ERR_* constants.
+const (
+	UploadErrOK        = 0 // UPLOAD_ERR_OK
+	UploadErrIniSize   = 1 // UPLOAD_ERR_INI_SIZE: larger than UploadOptions.MaxFileSize
+	UploadErrFormSize  = 2 // UPLOAD_ERR_FORM_SIZE: larger than a preceding MAX_FILE_SIZE field
+	UploadErrPartial   = 3 // UPLOAD_ERR_PARTIAL
+	UploadErrNoFile    = 4 // UPLOAD_ERR_NO_FILE: file field sent with an empty filename
+	UploadErrNoTmpDir  = 6 // UPLOAD_ERR_NO_TMP_DIR
+	UploadErrCantWrite = 7 // UPLOAD_ERR_CANT_WRITE: the temp file could not be written
+	UploadErrExtension = 8 // UPLOAD_ERR_EXTENSION
+)
+
+// UploadedFile is one file part of a multipart/form-data body.
+type UploadedFile struct {
+	Field    string // form field name as sent, e.g. "doc[attachments][]"
+	Name     string // client file name without directories (PHP's name)
+	FullPath string // client file name as sent (PHP 8.1's full_path)
+	Type     string // client Content-Type; empty when Error is set
+	TmpName  string // spooled temp file; empty when kept in memory or when Error is set
+	Error    int    // one of the UploadErr* codes
+	Size     int64  // bytes stored; 0 when Error is set
+
+	data []byte // content when kept in memory
+}
+
+// Open returns the content of a successfully uploaded file.
+func (f *UploadedFile) Open() (io.ReadCloser, error) {
+	if f.Error != UploadErrOK {
+		return nil, fmt.Errorf("upload %s: error code %d", f.Field, f.Error)
+	}
+	if f.TmpName != "" {
+		return os.Open(f.TmpName)
+	}
+	return io.NopCloser(bytes.NewReader(f.data)), nil
+}
+
+// MultipartForm is the result of ParseMultipart.
+type MultipartForm struct {
+	Post    map[string]any  // text fields, like $_POST
+	Files   map[string]any  // file fields, like $_FILES (or the sane layout, see UploadOptions)
+	Uploads []*UploadedFile // every file registered in Files, in body order
+}
+
+// RemoveAll deletes the temp files of all uploads.
+func (f *MultipartForm) RemoveAll() error {
+	var errs []error
+	for _, u := range f.Uploads {
+		if u.TmpName != "" {
+			if err := os.Remove(u.TmpName); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ParseMultipart parses a multipart/form-data body the way PHP fills $_POST and $_FILES.
+//
+// Field names of both text and file parts go through the same tokenizing, limits and insert
+// rules as ParseStrWithOptions, but nothing is percent-decoded and text values are kept
+// byte-for-byte. In the default layout a file field doc[attachments][] adds its name, full_path,
+// type, tmp_name, error and size under Files["doc"]["name"]["attachments"][i] and so on,
+// exactly like PHP's $_FILES; error and size are ints, the rest strings.
+// A *LimitError raised here reports the zero-based part index as Offset.
+//
+// On error every temp file created so far is removed. Otherwise the caller owns the temp
+// files and should call RemoveAll when done.
+func ParseMultipart(r io.Reader, boundary string, opts Options, uopts UploadOptions) (*MultipartForm, error) {
+	form := &MultipartForm{Post: make(map[string]any), Files: make(map[string]any)}
+	fields := &pairWalker{opts: opts, b: mapBuilder(form.Post), unescapeKey: keepEscapes, rawValues: true}
+	fb := &filesBuilder{root: form.Files, sane: uopts.SaneFilesLayout}
+	files := &pairWalker{opts: opts, b: fb, unescapeKey: keepEscapes}
+
+	fail := func(err error) (*MultipartForm, error) {
+		form.RemoveAll()
+		return nil, err
+	}
+	var formMax int64 // last MAX_FILE_SIZE field
+	mr := multipart.NewReader(r, boundary)
+	for i := 0; ; i++ {
+		part, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fail(fmt.Errorf("parse multipart: %w", err))
+		}
+		disposition, params, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+		if err != nil || disposition != "form-data" || params["name"] == "" {
+			continue // PHP ignores parts it cannot name
+		}
+		name := params["name"]
+		filename, isFile := params["filename"]
+
+		if !isFile {
+			if fields.stopped {
+				continue
+			}
+			var src io.Reader = part
+			if opts.MaxValueLength > 0 {
+				src = io.LimitReader(part, int64(opts.MaxValueLength)+1) // enough to detect the limit
+			}
+			value, err := io.ReadAll(src)
+			if err != nil {
+				return fail(fmt.Errorf("parse multipart field %q: %w", name, err))
+			}
+			if name == "MAX_FILE_SIZE" {
+				formMax, _ = strconv.ParseInt(strings.TrimSpace(string(value)), 10, 64)
+			}
+			if err := fields.count(name, i); err != nil {
+				return fail(err)
+			}
+			if fields.stopped {
+				continue
+			}
+			if err := fields.field(name, string(value), i, name); err != nil {
+				return fail(err)
+			}
+			continue
+		}
+
+		if uopts.MaxFileUploads > 0 && len(form.Uploads) >= uopts.MaxFileUploads {
+			continue
+		}
+		f := &UploadedFile{Field: name, FullPath: filename, Name: fileBaseName(filename)}
+		if filename == "" {
+			f.Error = UploadErrNoFile
+		} else {
+			f.Type = part.Header.Get("Content-Type")
+			if err := storeUpload(f, part, uopts, formMax); err != nil {
+				return fail(fmt.Errorf("parse multipart file %q: %w", name, err))
+			}
+		}
+		fb.file, fb.used = f, false
+		if err := files.field(name, "", i, name); err != nil {
+			removeUpload(f)
+			return fail(err)
+		}
+		if !fb.used {
+			removeUpload(f) // dropped by a limit
+			continue
+		}
+		form.Uploads = append(form.Uploads, f)
+	}
+	return form, nil
+}
+
+// filesBuilder registers the current file under a field path, in PHP's or the sane layout.
+type filesBuilder struct {
+	root map[string]any
+	sane bool
+	file *UploadedFile
+	used bool // file was inserted
+}
+
+func (b *filesBuilder) insert(base string, tokens []string, _ any) {
+	b.used = true
+	if b.sane {
+		insert(b.root, base, tokens, b.file)
+		return
+	}
+	f := b.file
+	props := []struct {
+		key   string
+		value any
+	}{
+		{"name", f.Name},
+		{"full_path", f.FullPath},
+		{"type", f.Type},
+		{"tmp_name", f.TmpName},
+		{"error", f.Error},
+		{"size", int(f.Size)},
+	}
+	// PHP transposes: the property comes right after the base, then the field's own tokens
+	for _, p := range props {
+		insert(b.root, base, append([]string{p.key}, tokens...), p.value)
+	}
+}
+
+func (b *filesBuilder) remove(base string) {
+	delete(b.root, base)
+}
+
+// storeUpload reads a file part into memory or a temp file and fills Size/TmpName/Error.
+// Only read errors of the body itself are returned; storage problems become upload error codes.
+func storeUpload(f *UploadedFile, part io.Reader, uopts UploadOptions, formMax int64) error {
+	var src io.Reader = part
+	if uopts.MaxFileSize > 0 {
+		src = io.LimitReader(part, uopts.MaxFileSize+1) // enough to detect the limit
+	}
+	var mem bytes.Buffer
+	n, err := io.CopyN(&mem, src, uopts.MaxMemory+1)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if uopts.MaxMemory > 0 && n <= uopts.MaxMemory { // 0 spools even empty files, like PHP
+		f.data, f.Size = mem.Bytes(), n
+	} else {
+		tmp, err := os.CreateTemp(uopts.TempDir, "php")
+		if err != nil {
+			return rejectUpload(f, part, UploadErrNoTmpDir)
+		}
+		f.TmpName = tmp.Name()
+		tw := &trackedWriter{w: tmp}
+		n, err = io.Copy(tw, io.MultiReader(&mem, src))
+		if cerr := tmp.Close(); cerr != nil && tw.err == nil {
+			tw.err = cerr
+		}
+		if tw.err != nil {
+			return rejectUpload(f, part, UploadErrCantWrite)
+		}
+		if err != nil {
+			removeUpload(f)
+			return err
+		}
+		f.Size = n
+	}
+	switch {
+	case uopts.MaxFileSize > 0 && f.Size > uopts.MaxFileSize:
+		return rejectUpload(f, part, UploadErrIniSize)
+	case formMax > 0 && f.Size > formMax:
+		return rejectUpload(f, part, UploadErrFormSize)
+	}
+	return nil
+}
+
+// rejectUpload marks f as failed with code like PHP does (no type, tmp_name or size) and skips the rest of part.
+func rejectUpload(f *UploadedFile, part io.Reader, code int) error {
+	removeUpload(f)
+	f.Error, f.Type, f.Size, f.data = code, "", 0, nil
+	_, err := io.Copy(io.Discard, part)
+	return err
+}
+
+// removeUpload deletes f's temp file, if any.
+func removeUpload(f *UploadedFile) {
+	if f.TmpName != "" {
+		os.Remove(f.TmpName)
+		f.TmpName = ""
+	}
+}
+
+// trackedWriter remembers the first write error, to tell storage failures from read failures.
+type trackedWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (t *trackedWriter) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+	return n, err
+}
+
+// fileBaseName strips client directories from a file name like PHP's php_ap_basename.
+func fileBaseName(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// keepEscapes is the pairWalker unescape function for names that are not urlencoded (multipart, cookies).
+func keepEscapes(s string, _ bool) (string, error) {
+	return s, nil
+}
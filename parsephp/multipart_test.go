@@ -0,0 +1,153 @@
+package parsephp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type testPart struct {
+	name, filename, contentType, body string
+	isFile                            bool
+}
+
+func multipartBody(t *testing.T, parts []testPart) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		h := textproto.MIMEHeader{}
+		if p.isFile {
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, p.name, p.filename))
+			h.Set("Content-Type", p.contentType)
+		} else {
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q`, p.name))
+		}
+		w, err := mw.CreatePart(h)
+		if err != nil {
+			t.Fatalf("create part: %v", err)
+		}
+		io.WriteString(w, p.body)
+	}
+	mw.Close()
+	return &buf, mw.Boundary()
+}
+
+func TestParseMultipart_PHPFilesLayout(t *testing.T) {
+	body, boundary := multipartBody(t, []testPart{
+		{name: "doc[title]", body: "  Report %41+  "},
+		{name: "doc[attachments][]", filename: "dir/a.pdf", contentType: "application/pdf", body: "AAAA", isFile: true},
+		{name: "doc[attachments][]", filename: "b.txt", contentType: "text/plain", body: "BB", isFile: true},
+		{name: "avatar", filename: "", isFile: true},
+	})
+	form, err := ParseMultipart(body, boundary, DefaultOptions, UploadOptions{MaxMemory: 1 << 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer form.RemoveAll()
+
+	wantPost := map[string]any{"doc": map[string]any{"title": "  Report %41+  "}}
+	if !reflect.DeepEqual(form.Post, wantPost) {
+		t.Fatalf("post: got %#v, want %#v", form.Post, wantPost)
+	}
+	wantFiles := map[string]any{
+		"doc": map[string]any{
+			"name":      map[string]any{"attachments": []any{"a.pdf", "b.txt"}},
+			"full_path": map[string]any{"attachments": []any{"dir/a.pdf", "b.txt"}},
+			"type":      map[string]any{"attachments": []any{"application/pdf", "text/plain"}},
+			"tmp_name":  map[string]any{"attachments": []any{"", ""}},
+			"error":     map[string]any{"attachments": []any{UploadErrOK, UploadErrOK}},
+			"size":      map[string]any{"attachments": []any{4, 2}},
+		},
+		"avatar": map[string]any{
+			"name": "", "full_path": "", "type": "", "tmp_name": "", "error": UploadErrNoFile, "size": 0,
+		},
+	}
+	if !reflect.DeepEqual(form.Files, wantFiles) {
+		t.Fatalf("files: got %#v, want %#v", form.Files, wantFiles)
+	}
+	rc, err := form.Uploads[1].Open()
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	content, _ := io.ReadAll(rc)
+	if string(content) != "BB" {
+		t.Fatalf("content: got %q, want %q", content, "BB")
+	}
+}
+
+func TestParseMultipart_SaneLayoutSpoolingAndErrors(t *testing.T) {
+	body, boundary := multipartBody(t, []testPart{
+		{name: "MAX_FILE_SIZE", body: "6"},
+		{name: "f[big]", filename: "big.bin", contentType: "application/octet-stream", body: "0123456789", isFile: true},
+		{name: "f[ok]", filename: "ok.bin", contentType: "application/octet-stream", body: "012345", isFile: true},
+		{name: "f[skipped]", filename: "x.bin", contentType: "application/octet-stream", body: "x", isFile: true},
+	})
+	uopts := UploadOptions{TempDir: t.TempDir(), MaxFileUploads: 2, SaneFilesLayout: true}
+	form, err := ParseMultipart(body, boundary, DefaultOptions, uopts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := form.Files["f"].(map[string]any)
+	if len(f) != 2 {
+		t.Fatalf("got %d files, want 2 (MaxFileUploads)", len(f))
+	}
+	big := f["big"].(*UploadedFile)
+	if big.Error != UploadErrFormSize || big.TmpName != "" || big.Size != 0 || big.Type != "" {
+		t.Fatalf("big: got %+v, want UploadErrFormSize without storage", big)
+	}
+	ok := f["ok"].(*UploadedFile)
+	if ok.Error != UploadErrOK || ok.TmpName == "" || ok.Size != 6 {
+		t.Fatalf("ok: got %+v, want spooled file of 6 bytes", ok)
+	}
+	if err := form.RemoveAll(); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err := os.Stat(ok.TmpName); !os.IsNotExist(err) {
+		t.Fatalf("temp file still exists: %v", err)
+	}
+}
+
+func TestParseRequest_Multipart(t *testing.T) {
+	body, boundary := multipartBody(t, []testPart{
+		{name: "a", body: "post"},
+		{name: "up", filename: "u.txt", contentType: "text/plain", body: "U", isFile: true},
+	})
+	r := httptest.NewRequest(http.MethodPost, "/?a=get&b=1", body)
+	r.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	got, err := ParseRequest(r, RequestOptions{Uploads: UploadOptions{MaxMemory: 64}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]any{"a": "post", "b": "1"}; !reflect.DeepEqual(got.Request, want) {
+		t.Fatalf("request: got %#v, want %#v", got.Request, want)
+	}
+	if len(got.Uploads) != 1 || got.Files["up"].(map[string]any)["name"] != "u.txt" {
+		t.Fatalf("files: got %#v", got.Files)
+	}
+}
+
+func TestParseMultipart_EmptyFileSpooled(t *testing.T) {
+	body, boundary := multipartBody(t, []testPart{
+		{name: "empty", filename: "empty.txt", contentType: "text/plain", body: "", isFile: true},
+	})
+	form, err := ParseMultipart(body, boundary, DefaultOptions, UploadOptions{TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer form.RemoveAll()
+	f := form.Uploads[0]
+	if f.Error != UploadErrOK || f.TmpName == "" || f.Size != 0 {
+		t.Fatalf("got %+v, want spooled empty file", f)
+	}
+	if st, err := os.Stat(f.TmpName); err != nil || st.Size() != 0 {
+		t.Fatalf("temp file: %v, %v", st, err)
+	}
+}
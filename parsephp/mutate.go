@@ -0,0 +1,80 @@
+package parsephp
+
+import "strconv"
+
+// Set stores value at path in a ParseStr/ParseStrWithOptions result, with the insert rules
+// of the parser: "[]" appends, missing containers are created from the next token, and a
+// scalar in the way is promoted or replaced exactly as for a later pair of the query.
+// path is a key in bracket syntax with decoded text ("tenant[id]", "a[b][]"); the base and
+// tokens are trimmed like parsed keys, but MangleNames is not applied. For a string value,
+// the result equals a parse of the query with the pair path=value appended. A nil result
+// cannot be written to and gives an error.
+func Set(result map[string]any, path string, value any) error {
+	base, tokens, ok := splitPath(path)
+	if !ok {
+		return &FieldError{Path: path, Msg: "empty variable name"}
+	}
+	if result == nil {
+		return &FieldError{Path: path, Msg: "nil result map"}
+	}
+	insert(result, base, tokens, value)
+	return nil
+}
+
+// Delete removes the value at path (the syntax of Get) and reports whether there was one.
+// Trailing slice holes are trimmed and containers left empty are removed up to the base;
+// indices of the remaining elements do not change. The result equals a parse of the query
+// without the pairs that built the value after an HTTPBuildQuery round-trip, not always
+// before it: a list that a named key turned into a map stays a map ("a[]=x&a[b]=y" minus
+// "a[b]" leaves map{"0": "x"} where a parse of "a[]=x" gives a slice).
+func Delete(result map[string]any, path string) bool {
+	base, tokens, ok := splitPath(path)
+	if !ok {
+		return false
+	}
+	if _, ok := Get(result, path); !ok {
+		return false
+	}
+	if len(tokens) == 0 {
+		delete(result, base)
+		return true
+	}
+	if deleteIn(result[base], tokens) {
+		delete(result, base)
+	} else if sl, ok := result[base].([]any); ok {
+		result[base] = trimHoles(sl)
+	}
+	return true
+}
+
+// deleteIn removes the element at tokens below container, which Get has found, and
+// reports whether container is empty afterwards.
+func deleteIn(container any, tokens []string) bool {
+	tok, rest := tokens[0], tokens[1:]
+	switch c := container.(type) {
+	case map[string]any:
+		if len(rest) == 0 || deleteIn(c[tok], rest) {
+			delete(c, tok)
+		} else if sl, ok := c[tok].([]any); ok {
+			c[tok] = trimHoles(sl)
+		}
+		return len(c) == 0
+	case []any:
+		n, _ := strconv.Atoi(tok)
+		if len(rest) == 0 || deleteIn(c[n], rest) {
+			c[n] = nil
+		} else if sl, ok := c[n].([]any); ok {
+			c[n] = trimHoles(sl)
+		}
+		return len(trimHoles(c)) == 0
+	}
+	return false
+}
+
+// trimHoles drops the nil holes at the end of sl.
+func trimHoles(sl []any) []any {
+	for len(sl) > 0 && sl[len(sl)-1] == nil {
+		sl = sl[:len(sl)-1]
+	}
+	return sl
+}
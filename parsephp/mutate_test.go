@@ -0,0 +1,119 @@
+package parsephp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Set and Delete must leave the result a parse of the rewritten query would produce,
+// and HTTPBuildQuery must round-trip it.
+func checkRewrite(t *testing.T, name string, got map[string]any, rewritten string) {
+	t.Helper()
+	want, err := ParseStr(rewritten)
+	if err != nil {
+		t.Fatalf("%s: parse %q: %v", name, rewritten, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("%s: got %#v, want %#v", name, got, want)
+	}
+	q, err := HTTPBuildQuery(got, DefaultBuildOptions)
+	if err != nil {
+		t.Fatalf("%s: build: %v", name, err)
+	}
+	if again, _ := ParseStr(q); !reflect.DeepEqual(again, want) {
+		t.Fatalf("%s: rebuilt %q: got %#v, want %#v", name, q, again, want)
+	}
+}
+
+func TestSet(t *testing.T) {
+	cases := []struct {
+		query, path, value string
+	}{
+		{"x=1", "tenant[id]", "42"},
+		{"a[b][]=x", "a[b][]", "y"},
+		{"a[b][5]=x", "a[b][]", "y"},
+		{"a[x]=1&a[0]=2", "a[]", "3"},
+		{"a=1", "a[]", "2"},          // scalar becomes the first element
+		{"a=1", "a[b]", "2"},         // scalar discarded
+		{"a[b]=1", "a[b][c]", "2"},   // nested scalar replaced
+		{"a[]=1&a[]=2", "a[b]", "3"}, // list turns into a map
+		{"a[0][]=1", "a[][x]", "2"},
+		{"a[b]=1", "a[b]", "2"},
+		{"q=1", " p [ k ] ", "v"},
+	}
+	for _, c := range cases {
+		got, _ := ParseStr(c.query)
+		if err := Set(got, c.path, c.value); err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.path, err)
+		}
+		checkRewrite(t, c.query+" + "+c.path, got, c.query+"&"+c.path+"="+c.value)
+	}
+
+	if err := Set(map[string]any{}, "[a]", "x"); err == nil {
+		t.Fatalf("empty base: got no error")
+	}
+	var fe *FieldError
+	if err := Set(nil, "a", "x"); !errors.As(err, &fe) || fe.Path != "a" {
+		t.Fatalf("nil result: got %v, want *FieldError for a", err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	cases := []struct {
+		query, path, rewritten string
+		found                  bool
+	}{
+		{"a=1&debug[x]=1&debug[y][]=2", "debug", "a=1", true},
+		{"a[]=x&a[]=y&a[]=z", "a[2]", "a[]=x&a[]=y", true},
+		{"a[]=x&a[]=y&a[]=z", "a[0]", "a[1]=y&a[2]=z", true},
+		{"a[]=x&a[]=y&a[]=z", "a[1]", "a[0]=x&a[2]=z", true},
+		{"a[0]=x&a[3]=y", "a[3]", "a[0]=x", true}, // trailing hole trimmed too
+		{"a[b][c]=1&z=2", "a[b][c]", "z=2", true}, // emptied parents removed
+		{"a[b][]=1&a[b][]=2&a[c]=3", "a[b][1]", "a[b][]=1&a[c]=3", true},
+		{"a[0][0]=1&a[1]=2", "a[0][0]", "a[1]=2", true},
+		{"a[1][0]=1&a[0]=2", "a[1][0]", "a[0]=2", true},
+		{"a[b]=1", "a[c]", "a[b]=1", false},
+		{"a[]=1", "a[]", "a[]=1", false},
+		{"a=1", "a[0]", "a=1", false},
+	}
+	for _, c := range cases {
+		got, _ := ParseStr(c.query)
+		if found := Delete(got, c.path); found != c.found {
+			t.Fatalf("%s - %s: found %v, want %v", c.query, c.path, found, c.found)
+		}
+		checkRewrite(t, c.query+" - "+c.path, got, c.rewritten)
+	}
+
+	// a list turned into a map by a named key stays a map; it only equals the parse of the
+	// rewritten query after a round-trip
+	got, _ := ParseStr("a[]=x&a[b]=y")
+	Delete(got, "a[b]")
+	if want := map[string]any{"a": map[string]any{"0": "x"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("a[] - a[b]: got %#v, want %#v", got, want)
+	}
+	q, err := HTTPBuildQuery(got, DefaultBuildOptions)
+	if err != nil {
+		t.Fatalf("a[] - a[b]: build: %v", err)
+	}
+	again, _ := ParseStr(q)
+	if want, _ := ParseStr("a[]=x"); !reflect.DeepEqual(again, want) {
+		t.Fatalf("a[] - a[b]: rebuilt %q: got %#v, want %#v", q, again, want)
+	}
+}
+
+func TestSetGetDelete_TrimmedPathRoundTrip(t *testing.T) {
+	r, _ := ParseStr("x=1")
+	if err := Set(r, " a [ c ]", "v"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, path := range []string{" a [ c ]", "a[c]", "a[ c]"} {
+		if got, ok := Get(r, path); !ok || got != "v" {
+			t.Fatalf("Get(%q): got %#v, %v; want \"v\"", path, got, ok)
+		}
+	}
+	if !Delete(r, "a[ c ] ") {
+		t.Fatalf("Delete: value not found")
+	}
+	checkRewrite(t, "delete", r, "x=1")
+}
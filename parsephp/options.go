@@ -6,11 +6,45 @@ package parsephp
 // Separators: characters used to split pairs. Defaults to '&' and ';' (to mirror PHP's arg_separator.input).
 // StrictDecode: if true, decoding errors (malformed percent-escapes) will be returned as errors.
 //              if false, decoder is lenient: invalid escape sequences are kept as-is without failing the whole parse.
+// MangleNames: if true, the base key follows PHP's variable name rules: leading spaces are skipped and
+//              '.' and ' ' before the first '[' become '_' (user.name -> user_name). Bracket tokens are untouched.
+//              if false (default), the base is only trimmed, and only an unmatched '[' becomes '_'.
+// Charset: encoding of the submitted form, e.g. Windows1252 or GBK (see LookupCharset). Keys and values
+//              are converted to UTF-8 after percent decoding. nil (default) means UTF-8: bytes are kept as-is.
+// InvalidUTF8: what to do with a base key, bracket token or value that is not valid UTF-8 after decoding
+//              (and Charset conversion): keep it, replace each bad byte with U+FFFD, drop the pair, or fail
+//              with a *UTF8Error. The zero value keeps the bytes; StrictDecode does not change it.
+// RawLeaves: if true, every leaf is a Leaf holding the raw key and value substrings next to the decoded
+//              value, instead of a string. if false (default), leaves are decoded strings.
+//
+// Limits (zero disables a limit; the PHP ini default is given where one exists):
+// MaxInputVars: maximum number of non-empty pairs; later pairs are ignored (max_input_vars, PHP default 1000).
+// MaxNestingLevel: maximum number of bracket tokens per key; an over-nested pair is dropped and the whole
+//              top-level variable it targets is removed (max_input_nesting_level, PHP default 64).
+// MaxIndex: maximum numeric bracket index; pairs using a larger index are dropped. Even without it an
+//              index never adds more than 1024 nil holes to a slice: a farther one (a[999999999]) turns the
+//              slice into a map with string keys. What remains is memory proportional to the input, e.g. up to
+//              1024 holes per pair, so MaxInputVars and MaxTotalBytes are still needed for untrusted input.
+// MaxKeyLength / MaxValueLength: maximum raw (undecoded) key / value length in bytes; longer pairs are dropped.
+// MaxTotalBytes: maximum input length in bytes; a longer input yields an empty result (like post_max_size).
+// StrictLimits: if true, the first exceeded limit is returned as a *LimitError instead of dropping input.
 //
 // Note: ParseStr uses DefaultOptions.
 type Options struct {
     Separators   []rune
     StrictDecode bool
+    MangleNames  bool
+    Charset      Charset
+    InvalidUTF8  InvalidUTF8Policy
+    RawLeaves    bool
+
+    MaxInputVars    int
+    MaxNestingLevel int
+    MaxIndex        int
+    MaxKeyLength    int
+    MaxValueLength  int
+    MaxTotalBytes   int
+    StrictLimits    bool
 }
 
 // DefaultOptions used by ParseStr.
@@ -18,3 +52,141 @@ var DefaultOptions = Options{
     Separators:   []rune{'&', ';'},
     StrictDecode: false,
 }
+
+// PHPOptions is the "PHP fidelity" preset: PHP's variable name mangling, PHP's default
+// arg_separator.input ("&" only) and the default max_input_vars / max_input_nesting_level.
+var PHPOptions = Options{
+    Separators:      []rune{'&'},
+    StrictDecode:    false,
+    MangleNames:     true,
+    MaxInputVars:    1000,
+    MaxNestingLevel: 64,
+}
+
+// InvalidUTF8Policy selects how ParseStrWithOptions treats malformed UTF-8 in keys and values.
+type InvalidUTF8Policy int
+
+const (
+    // InvalidUTF8Keep passes the bytes through unchanged, like PHP. It is the zero value,
+    // also under StrictDecode.
+    InvalidUTF8Keep InvalidUTF8Policy = iota
+    // InvalidUTF8Replace replaces every byte that is not part of a valid sequence with U+FFFD.
+    InvalidUTF8Replace
+    // InvalidUTF8Drop ignores the whole pair.
+    InvalidUTF8Drop
+    // InvalidUTF8Error fails the parse with a *UTF8Error.
+    InvalidUTF8Error
+)
+
+// EncType selects the percent-encoding flavor used by HTTPBuildQuery (PHP's enc_type).
+type EncType int
+
+const (
+    // EncRFC1738 mirrors PHP_QUERY_RFC1738 (urlencode): spaces become '+'.
+    EncRFC1738 EncType = iota
+    // EncRFC3986 mirrors PHP_QUERY_RFC3986 (rawurlencode): spaces become %20 and '~' is kept.
+    EncRFC3986
+)
+
+// BuildOptions defines configurable behavior for HTTPBuildQuery, mirroring http_build_query's parameters.
+//
+// NumericPrefix: prepended to integer keys at the top level only (PHP's numeric_prefix).
+// ArgSeparator: placed between pairs. Defaults to "&" (PHP's arg_separator.output).
+// EncType: EncRFC1738 (default) or EncRFC3986.
+// EncodeBrackets: if true, structural brackets are emitted as %5B/%5D exactly like PHP.
+//              if false (default), they are emitted literally, which ParseStr needs to see structure
+//              because keys are tokenized before decoding.
+type BuildOptions struct {
+    NumericPrefix  string
+    ArgSeparator   string
+    EncType        EncType
+    EncodeBrackets bool
+}
+
+// DefaultBuildOptions used when callers have no specific needs.
+var DefaultBuildOptions = BuildOptions{
+    ArgSeparator: "&",
+    EncType:      EncRFC1738,
+}
+
+// CanonicalOptions defines configurable behavior for Canonicalize.
+//
+// Options: parsing options (the zero value behaves like DefaultOptions). The first of Separators joins
+//              the output pairs; Charset is not supported.
+// Exclude: top-level variable names to leave out, as path.Match patterns on the decoded name
+//              ("utm_*", "fbclid"); the whole variable is dropped, nested keys included.
+// EncType: percent-encoding of the output, EncRFC1738 (default, spaces as '+') or EncRFC3986.
+type CanonicalOptions struct {
+    Options Options
+    Exclude []string
+    EncType EncType
+}
+
+// DefaultCanonicalOptions used when callers have no specific needs.
+var DefaultCanonicalOptions = CanonicalOptions{
+    Options: DefaultOptions,
+    EncType: EncRFC1738,
+}
+
+// RequestOptions defines configurable behavior for ParseRequest.
+//
+// Options: parsing options applied to both the URL query and the body (the zero value behaves like DefaultOptions).
+// RequestOrder: sources merged into Request, in order, later ones overwriting earlier ones
+//              (PHP's request_order): 'G' for the query, 'P' for the body and 'C' for cookies. Defaults to "GP".
+// MaxBodyBytes: maximum body size read; a larger body fails with *http.MaxBytesError. Defaults to 10 MB.
+// Uploads: file handling for multipart/form-data bodies.
+type RequestOptions struct {
+    Options      Options
+    RequestOrder string
+    MaxBodyBytes int64
+    Uploads      UploadOptions
+}
+
+// DefaultRequestOptions used when callers have no specific needs.
+var DefaultRequestOptions = RequestOptions{
+    Options:      DefaultOptions,
+    RequestOrder: "GP",
+    MaxBodyBytes: 10 << 20,
+}
+
+// UploadOptions defines how ParseMultipart stores file parts.
+//
+// MaxMemory: files up to this many bytes are kept in memory; larger ones are spooled to a temp file.
+//              0 (default) spools every file, like PHP.
+// TempDir: directory for spooled files; "" means os.TempDir() (PHP's upload_tmp_dir).
+// MaxFileSize: larger files are discarded with UploadErrIniSize (upload_max_filesize); 0 means unlimited.
+// MaxFileUploads: file parts beyond this count are ignored (max_file_uploads, PHP default 20); 0 means unlimited.
+// SaneFilesLayout: if true, Files holds one *UploadedFile per field path (doc[attachments][0] => file)
+//              instead of PHP's transposed name/type/tmp_name/error/size arrays.
+type UploadOptions struct {
+    MaxMemory       int64
+    TempDir         string
+    MaxFileSize     int64
+    MaxFileUploads  int
+    SaneFilesLayout bool
+}
+
+// CookieOptions defines configurable behavior for ParseCookie.
+//
+// Options: key handling, decoding strictness and limits; Separators is ignored (cookies are split on ';').
+// LastWins: if true, a repeated top-level cookie name overwrites the earlier value.
+//              if false (default), the first one wins like PHP (browsers send the most specific cookie first).
+type CookieOptions struct {
+    Options  Options
+    LastWins bool
+}
+
+// UnserializeOptions defines the limits of UnserializeWithOptions.
+//
+// MaxDepth: maximum nesting of arrays (like unserialize_max_depth); 0 means unlimited.
+// MaxBytes: maximum payload length in bytes; 0 means unlimited.
+type UnserializeOptions struct {
+    MaxDepth int
+    MaxBytes int
+}
+
+// DefaultUnserializeOptions used by Unserialize; MaxDepth is PHP's unserialize_max_depth default.
+var DefaultUnserializeOptions = UnserializeOptions{
+    MaxDepth: 4096,
+    MaxBytes: 10 << 20,
+}
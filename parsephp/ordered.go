@@ -0,0 +1,87 @@
+package parsephp
+
+// ParseStrOrdered parses a raw query string using DefaultOptions into an insertion-ordered *Array,
+// so iterating the result visits keys in the order PHP's foreach would.
+func ParseStrOrdered(query string) (*Array, error) {
+	return ParseStrOrderedWithOptions(query, DefaultOptions)
+}
+
+// ParseStrOrderedWithOptions is like ParseStrOrdered but allows configuration via Options.
+//
+// Pair splitting, decoding and the scalar/array conversion rules are the same as ParseStrWithOptions.
+// The differences come from the container: an Array has no slice/map split, so
+// - numeric tokens become integer keys and gaps stay gaps (a[0]=b&a[2]=c has keys 0 and 2, no nil hole)
+// - `[]` appends at the next automatic index (max integer key + 1), whatever other keys exist
+// - a[x]=1&a[y]=2&a[0]=3 iterates x, y, 0
+func ParseStrOrderedWithOptions(query string, opts Options) (*Array, error) {
+	root := NewArray()
+	if err := walkPairs(query, opts, arrayBuilder{root}); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// arrayBuilder builds the *Array returned by ParseStrOrderedWithOptions.
+type arrayBuilder struct {
+	root *Array
+}
+
+func (b arrayBuilder) insert(base string, tokens []string, value any) {
+	insertOrdered(b.root, base, tokens, value)
+}
+
+func (b arrayBuilder) remove(base string) {
+	b.root.Delete(StringKey(base))
+}
+
+// insertOrdered is the *Array counterpart of insert and applies the same rules:
+// - plain scalars are last-wins
+// - a scalar base followed by `[]`/numeric becomes the first element; followed by key[sub] it is discarded
+// - below the base, a scalar in the way of a deeper token is replaced by a fresh array
+func insertOrdered(root *Array, base string, tokens []string, value any) {
+	baseKey := StringKey(base)
+	if len(tokens) == 0 {
+		root.Set(baseKey, value)
+		return
+	}
+
+	cur := childArray(root, baseKey, tokens[0], true)
+	for idx, tok := range tokens {
+		if idx == len(tokens)-1 {
+			if tok == "" {
+				cur.Append(value)
+			} else {
+				cur.Set(StringKey(tok), value)
+			}
+			return
+		}
+		if tok == "" {
+			child := NewArray()
+			cur.Append(child)
+			cur = child
+			continue
+		}
+		cur = childArray(cur, StringKey(tok), tokens[idx+1], false)
+	}
+}
+
+// childArray returns the *Array stored at parent[k], creating or replacing it when needed.
+// With promote set, a string (or Leaf) found there becomes the first element of the new array when
+// next is "" or numeric (the base-level scalar-then-array rule of insert).
+func childArray(parent *Array, k Key, next string, promote bool) *Array {
+	existing, ok := parent.Get(k)
+	if ok {
+		if arr, isArr := existing.(*Array); isArr {
+			return arr
+		}
+	}
+	arr := NewArray()
+	if ok && promote && (next == "" || isNumeric(next)) {
+		switch existing.(type) {
+		case string, Leaf:
+			arr.Append(existing)
+		}
+	}
+	parent.Set(k, arr)
+	return arr
+}
@@ -0,0 +1,117 @@
+package parsephp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// keysOf renders an Array's keys in iteration order, marking integer keys with '#'.
+func keysOf(a *Array) []string {
+	var out []string
+	a.Range(func(k Key, _ any) bool {
+		if k.IsInt() {
+			out = append(out, "#"+k.String())
+		} else {
+			out = append(out, k.String())
+		}
+		return true
+	})
+	return out
+}
+
+func TestOrdered_IterationFollowsInput(t *testing.T) {
+	got, err := ParseStrOrdered("a[x]=1&a[y]=2&a[0]=3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v, ok := got.Get(StringKey("a"))
+	if !ok {
+		t.Fatalf("missing key a")
+	}
+	a := v.(*Array)
+	if want := []string{"x", "y", "#0"}; !reflect.DeepEqual(keysOf(a), want) {
+		t.Fatalf("got keys %#v, want %#v", keysOf(a), want)
+	}
+	if v, _ := a.Get(IntKey(0)); v != "3" {
+		t.Fatalf("a[0]=%#v, want '3'", v)
+	}
+}
+
+func TestOrdered_TopLevelOrderAndOverwriteKeepsPosition(t *testing.T) {
+	got, err := ParseStrOrdered("z=1&b=2&z=3&0=x")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"z", "b", "#0"}; !reflect.DeepEqual(keysOf(got), want) {
+		t.Fatalf("got keys %#v, want %#v", keysOf(got), want)
+	}
+	if v, _ := got.Get(StringKey("z")); v != "3" {
+		t.Fatalf("z=%#v, want '3'", v)
+	}
+}
+
+func TestOrdered_AppendUsesNextIndexAndKeepsGaps(t *testing.T) {
+	got, err := ParseStrOrdered("a[5]=x&a[b]=y&a[]=z&a[01]=w")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v, _ := got.Get(StringKey("a"))
+	a := v.(*Array)
+	if want := []string{"#5", "b", "#6", "01"}; !reflect.DeepEqual(keysOf(a), want) {
+		t.Fatalf("got keys %#v, want %#v", keysOf(a), want)
+	}
+}
+
+func TestOrdered_ScalarConversionsMatchParseStr(t *testing.T) {
+	got, err := ParseStrOrdered("a=1&a[]=2&b=1&b[c]=2&d[][e]=f")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v, _ := got.Get(StringKey("a"))
+	if want := []string{"#0", "#1"}; !reflect.DeepEqual(keysOf(v.(*Array)), want) {
+		t.Fatalf("a keys %#v, want %#v", keysOf(v.(*Array)), want)
+	}
+	if first, _ := v.(*Array).Get(IntKey(0)); first != "1" {
+		t.Fatalf("a[0]=%#v, want '1'", first)
+	}
+	v, _ = got.Get(StringKey("b"))
+	if want := []string{"c"}; !reflect.DeepEqual(keysOf(v.(*Array)), want) {
+		t.Fatalf("b keys %#v, want %#v", keysOf(v.(*Array)), want)
+	}
+	v, _ = got.Get(StringKey("d"))
+	inner, _ := v.(*Array).Get(IntKey(0))
+	if e, _ := inner.(*Array).Get(StringKey("e")); e != "f" {
+		t.Fatalf("d[0][e]=%#v, want 'f'", e)
+	}
+}
+
+func TestKey_CanonicalIntegers(t *testing.T) {
+	cases := []struct {
+		in    string
+		isInt bool
+	}{
+		{"0", true}, {"42", true}, {"-7", true},
+		{"07", false}, {"-0", false}, {"", false}, {"1e3", false}, {"99999999999999999999", false},
+	}
+	for _, c := range cases {
+		if got := StringKey(c.in).IsInt(); got != c.isInt {
+			t.Fatalf("StringKey(%q).IsInt()=%v, want %v", c.in, got, c.isInt)
+		}
+	}
+	if StringKey("3") != IntKey(3) {
+		t.Fatalf("StringKey(\"3\") should equal IntKey(3)")
+	}
+}
+
+func TestArray_DeleteDoesNotRewindAppend(t *testing.T) {
+	a := NewArray()
+	a.Append("x")
+	a.Append("y")
+	a.Delete(IntKey(1))
+	if k := a.Append("z"); k != IntKey(2) {
+		t.Fatalf("append key=%v, want 2", k)
+	}
+	if want := []string{"#0", "#2"}; !reflect.DeepEqual(keysOf(a), want) {
+		t.Fatalf("got keys %#v, want %#v", keysOf(a), want)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // ParseStr parses a raw query string using DefaultOptions and returns a nested structure
@@ -20,78 +21,337 @@ func ParseStr(query string) (map[string]any, error) {
 
 // ParseStrWithOptions is like ParseStr but allows configuration via Options.
 func ParseStrWithOptions(query string, opts Options) (map[string]any, error) {
+	root := make(map[string]any)
+	if err := walkPairs(query, opts, mapBuilder(root)); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// builder receives the decoded pairs of walkPairs and owns the result structure,
+// so every result shape (maps/slices, *Array, ...) shares the same pair-level rules.
+type builder interface {
+	// insert stores value under base following tokens; plain scalars (no tokens) are last-wins.
+	insert(base string, tokens []string, value any)
+	// remove deletes the whole top-level variable base.
+	remove(base string)
+}
+
+// mapBuilder builds the map[string]any/[]any trees returned by ParseStrWithOptions.
+type mapBuilder map[string]any
+
+func (m mapBuilder) insert(base string, tokens []string, value any) {
+	insert(m, base, tokens, value)
+}
+
+func (m mapBuilder) remove(base string) {
+	delete(m, base)
+}
+
+// walkPairs splits query per opts and feeds every pair to b, in input order.
+func walkPairs(query string, opts Options, b builder) error {
+	return (&pairWalker{opts: opts, b: b}).walk(query, true)
+}
+
+// walk splits input by the configured separators and processes every pair.
+// trimQuestion drops an optional leading '?' (query strings, not cookies).
+func (w *pairWalker) walk(input string, trimQuestion bool) error {
+	opts := w.opts
 	if len(opts.Separators) == 0 {
 		opts.Separators = DefaultOptions.Separators
 	}
 
+	if opts.MaxTotalBytes > 0 && len(input) > opts.MaxTotalBytes {
+		// PHP discards the whole input when it exceeds post_max_size
+		return w.limitHit("MaxTotalBytes", opts.MaxTotalBytes, opts.MaxTotalBytes, "")
+	}
+
 	// Trim optional leading '?'
-	if strings.HasPrefix(query, "?") {
-		query = query[1:]
+	offset := 0
+	if trimQuestion && strings.HasPrefix(input, "?") {
+		input = input[1:]
+		offset = 1
 	}
 
 	// Split pairs by the configured separators
-	pairs := splitBySeparators(query, opts.Separators)
-	root := make(map[string]any)
+	for _, seg := range splitBySeparators(input, opts.Separators) {
+		if err := w.pair(seg.text, offset+seg.offset); err != nil {
+			return err
+		}
+		if w.stopped {
+			break
+		}
+	}
+	return nil
+}
 
-	for _, raw := range pairs {
-		if raw == "" {
-			// ignore completely empty pairs (e.g., leading/trailing separators or double separators)
-			continue
+// pairWalker applies the pair-level rules of an Options (decoding, trimming, limits)
+// to raw pairs fed one at a time, and hands the survivors to a builder.
+type pairWalker struct {
+	opts    Options
+	b       builder
+	vars    int  // non-empty pairs seen, for MaxInputVars
+	stopped bool // MaxInputVars reached; later pairs are ignored
+
+	// unescapeKey / unescapeValue replace decode for keys / values; nil means decode.
+	unescapeKey   func(s string, strict bool) (string, error)
+	unescapeValue func(s string, strict bool) (string, error)
+	// rawValues keeps values byte-for-byte: no decoding, no trimming (multipart fields);
+	// only Options.Charset is applied.
+	rawValues bool
+	// keepValueSpace skips trimming of decoded values (cookies keep them byte-for-byte).
+	keepValueSpace bool
+
+	// warn, when set, receives every lossy decision (ParseStrWithDiagnostics).
+	warn func(Warning)
+	at   Warning // Offset and Pair of the pair being processed (warnings, positions)
+}
+
+// warning reports a lossy decision on the current pair to warn, if set.
+func (w *pairWalker) warning(kind WarningKind, path string, lost any, detail string) {
+	if w.warn == nil {
+		return
+	}
+	wr := w.at
+	wr.Kind, wr.Path, wr.Lost, wr.Detail = kind, path, lost, detail
+	w.warn(wr)
+}
+
+// decodeKey applies the walker's key unescaping and Options.Charset to s.
+func (w *pairWalker) decodeKey(s string) (string, error) {
+	unescape := decode
+	if w.unescapeKey != nil {
+		unescape = w.unescapeKey
+	}
+	d, err := unescape(s, w.opts.StrictDecode)
+	return w.convert(d), err
+}
+
+// decodeValue applies the walker's value unescaping and Options.Charset to s.
+func (w *pairWalker) decodeValue(s string) (string, error) {
+	unescape := decode
+	if w.unescapeValue != nil {
+		unescape = w.unescapeValue
+	}
+	d, err := unescape(s, w.opts.StrictDecode)
+	return w.convert(d), err
+}
+
+// convert turns decoded bytes in Options.Charset into UTF-8.
+func (w *pairWalker) convert(s string) string {
+	if w.opts.Charset == nil {
+		return s
+	}
+	return w.opts.Charset.Decode(s)
+}
+
+// count registers a non-empty pair against MaxInputVars.
+func (w *pairWalker) count(raw string, offset int) error {
+	w.vars++
+	if w.opts.MaxInputVars > 0 && w.vars > w.opts.MaxInputVars {
+		// PHP stops reading input once max_input_vars is exceeded
+		w.stopped = true
+		return w.limitHit("MaxInputVars", w.opts.MaxInputVars, offset, raw)
+	}
+	return nil
+}
+
+// limitHit drops the pair, or reports it when StrictLimits is set.
+func (w *pairWalker) limitHit(limit string, max, offset int, raw string) error {
+	if w.opts.StrictLimits {
+		return &LimitError{Limit: limit, Max: max, Offset: offset, Pair: raw}
+	}
+	if w.warn != nil {
+		detail := limit + " exceeded"
+		switch limit {
+		case "MaxInputVars":
+			detail += "; the rest of the input is ignored"
+		case "MaxTotalBytes":
+			detail += "; the whole input is ignored"
 		}
-		// Split once on first '='; key without '=' => empty value
-		k, v, hasEq := splitPair(raw)
+		w.warn(Warning{Kind: WarnLimitDropped, Offset: offset, Pair: raw, Lost: raw, Detail: detail})
+	}
+	return nil
+}
+
+// pair processes one raw pair found at byte offset in the original input.
+func (w *pairWalker) pair(raw string, offset int) error {
+	if raw == "" || w.stopped {
+		// ignore completely empty pairs (e.g., leading/trailing separators or double separators)
+		return nil
+	}
+	if err := w.count(raw, offset); err != nil || w.stopped {
+		return err
+	}
+
+	// Split once on first '='; key without '=' => empty value
+	k, v, hasEq := splitPair(raw)
+	_ = hasEq // only used to compute v empty when no '='; v already set
+	return w.field(k, v, offset, raw)
+}
 
-		// Decode value (keys are tokenized first to avoid encoded brackets becoming structural)
-		dv, errV := decode(v, opts.StrictDecode)
+// field processes the raw key and value of a pair that has already been counted.
+// raw and offset identify the pair in the input for error reporting.
+func (w *pairWalker) field(k, v string, offset int, raw string) error {
+	opts := w.opts
+	w.at = Warning{Offset: offset, Pair: raw}
+	limitHit := func(limit string, max int) error {
+		return w.limitHit(limit, max, offset, raw)
+	}
+	if opts.MaxKeyLength > 0 && len(k) > opts.MaxKeyLength {
+		return limitHit("MaxKeyLength", opts.MaxKeyLength)
+	}
+	if opts.MaxValueLength > 0 && len(v) > opts.MaxValueLength {
+		return limitHit("MaxValueLength", opts.MaxValueLength)
+	}
+
+	// Decode value (keys are tokenized first to avoid encoded brackets becoming structural)
+	dv := v
+	if w.rawValues {
+		dv = w.convert(v)
+	} else {
+		var errV error
+		dv, errV = w.decodeValue(v)
 		if opts.StrictDecode {
 			if errV != nil {
-				return nil, fmt.Errorf("decode value error: %w", errV)
+				return fmt.Errorf("decode value error: %w", errV)
 			}
 		}
-		dv = strings.TrimSpace(dv)
+		if !w.keepValueSpace {
+			dv = strings.TrimSpace(dv)
+		}
+	}
 
-		// Tokenize raw key into base + bracket tokens (before decoding)
-		rawSeq := tokenizeKey(k)
-		if len(rawSeq) == 0 {
-			continue
+	// Tokenize raw key into base + bracket tokens (before decoding)
+	rawSeq := tokenizeKey(k)
+	if len(rawSeq) == 0 {
+		w.warning(WarnEmptyKey, "", dv, "pair without a key dropped")
+		return nil
+	}
+	// Decode base and tokens individually
+	var base string
+	if opts.MangleNames {
+		var errK error
+		base, errK = mangleBase(k, rawSeq[0], w.decodeKey)
+		if opts.StrictDecode && errK != nil {
+			return fmt.Errorf("decode key error: %w", errK)
 		}
-		// Decode base and tokens individually
+	} else {
 		baseRaw := strings.TrimSpace(rawSeq[0])
-		base, errK := decode(baseRaw, opts.StrictDecode)
+		var errK error
+		base, errK = w.decodeKey(baseRaw)
 		if opts.StrictDecode && errK != nil {
-			return nil, fmt.Errorf("decode key error: %w", errK)
+			return fmt.Errorf("decode key error: %w", errK)
 		}
 		base = strings.TrimSpace(base)
-		var tokens []string
-		if len(rawSeq) > 1 {
-			tokens = make([]string, 0, len(rawSeq)-1)
-			for _, rt := range rawSeq[1:] {
-				dt, errT := decode(rt, opts.StrictDecode)
-				if opts.StrictDecode && errT != nil {
-					return nil, fmt.Errorf("decode key token error: %w", errT)
-				}
-				dt = strings.TrimSpace(dt)
-				tokens = append(tokens, dt)
+	}
+	var tokens []string
+	if len(rawSeq) > 1 {
+		tokens = make([]string, 0, len(rawSeq)-1)
+		for _, rt := range rawSeq[1:] {
+			dt, errT := w.decodeKey(rt)
+			if opts.StrictDecode && errT != nil {
+				return fmt.Errorf("decode key token error: %w", errT)
 			}
+			dt = strings.TrimSpace(dt)
+			tokens = append(tokens, dt)
 		}
+	}
 
-		if base == "" {
-			// ignore empty keys (robustness; PHP would create a variable with empty name, which is awkward in Go)
-			continue
+	if base == "" {
+		// ignore empty keys (robustness; PHP would create a variable with empty name, which is awkward in Go)
+		w.warning(WarnEmptyKey, "", dv, "pair with an empty variable name dropped")
+		return nil
+	}
+	if w.warn != nil && !opts.StrictDecode {
+		path := keyPath(base, tokens)
+		if w.unescapeKey == nil && hasInvalidEscape(k) {
+			w.warning(WarnLenientDecode, path, nil, "invalid percent-escape kept literally in key")
+		}
+		if w.unescapeValue == nil && !w.rawValues && hasInvalidEscape(v) {
+			w.warning(WarnLenientDecode, path, nil, "invalid percent-escape kept literally in value")
 		}
+	}
 
-		// Insert according to tokens
-		if len(tokens) == 0 {
-			// Plain scalar assignment, last wins policy
-			root[base] = dv
-			continue
+	if !validUTF8(base, tokens, dv) {
+		var err error
+		base, tokens, dv, err = w.invalidUTF8(base, tokens, dv, offset, raw)
+		if err != nil || base == "" {
+			return err
 		}
+	}
 
-		insert(root, base, tokens, dv)
-		_ = hasEq // only used to compute dv empty when no '='; dv already set
+	if opts.MaxNestingLevel > 0 && len(tokens) > opts.MaxNestingLevel {
+		// PHP drops the pair and unsets the whole variable when max_input_nesting_level is exceeded
+		if opts.StrictLimits {
+			return limitHit("MaxNestingLevel", opts.MaxNestingLevel)
+		}
+		w.b.remove(base)
+		return nil
+	}
+	if opts.MaxIndex > 0 {
+		for _, tok := range tokens {
+			if isDigits(tok) {
+				if n, err := strconv.Atoi(tok); err != nil || n > opts.MaxIndex {
+					return limitHit("MaxIndex", opts.MaxIndex)
+				}
+			}
+		}
 	}
 
-	return root, nil
+	// Hand over to the builder; plain scalars (no tokens) follow the last-wins policy there
+	var leaf any = dv
+	if opts.RawLeaves {
+		leaf = Leaf{RawKey: k, Raw: v, Value: dv}
+	}
+	w.b.insert(base, tokens, leaf)
+	return nil
+}
+
+// validUTF8 reports whether the decoded base, tokens and value are all valid UTF-8.
+func validUTF8(base string, tokens []string, value string) bool {
+	if !utf8.ValidString(base) || !utf8.ValidString(value) {
+		return false
+	}
+	for _, tok := range tokens {
+		if !utf8.ValidString(tok) {
+			return false
+		}
+	}
+	return true
+}
+
+// invalidUTF8 applies Options.InvalidUTF8 to a pair that failed validUTF8. An empty base
+// means the pair is dropped.
+func (w *pairWalker) invalidUTF8(base string, tokens []string, value string, offset int, raw string) (string, []string, string, error) {
+	path := keyPath(base, tokens)
+	part := "value"
+	if !utf8.ValidString(path) {
+		part = "key"
+	}
+	switch w.opts.InvalidUTF8 {
+	case InvalidUTF8Replace:
+		w.warning(WarnInvalidUTF8, toValidUTF8(path), nil, "malformed UTF-8 in "+part+" replaced with U+FFFD")
+		fixed := make([]string, len(tokens))
+		for i, tok := range tokens {
+			fixed[i] = toValidUTF8(tok)
+		}
+		return toValidUTF8(base), fixed, toValidUTF8(value), nil
+	case InvalidUTF8Drop:
+		w.warning(WarnInvalidUTF8, toValidUTF8(path), value, "pair with malformed UTF-8 in "+part+" dropped")
+		return "", nil, "", nil
+	case InvalidUTF8Error:
+		return "", nil, "", &UTF8Error{Offset: offset, Pair: raw, Part: part}
+	}
+	return base, tokens, value, nil
+}
+
+// toValidUTF8 replaces every byte of s that is not part of a valid UTF-8 sequence with U+FFFD.
+func toValidUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	return string([]rune(s))
 }
 
 // splitPair splits a raw pair into key and value, only on the first '='.
@@ -106,33 +366,39 @@ func splitPair(s string) (string, string, bool) {
 	return s, "", false
 }
 
+// segment is one separator-delimited part of the input and its byte offset there.
+type segment struct {
+	text   string
+	offset int
+}
+
 // splitBySeparators splits s by any rune in seps. Empty segments are preserved (caller may ignore).
-func splitBySeparators(s string, seps []rune) []string {
+func splitBySeparators(s string, seps []rune) []segment {
 	if s == "" {
-		return []string{}
+		return []segment{}
 	}
 	// Build a set for quick lookup
 	sepSet := make(map[rune]struct{}, len(seps))
 	for _, r := range seps {
 		sepSet[r] = struct{}{}
 	}
-	var out []string
-	var b strings.Builder
-	for _, r := range s {
-		if _, isSep := sepSet[r]; isSep {
-			out = append(out, b.String())
-			b.Reset()
-			continue
+	var out []segment
+	start := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if _, isSep := sepSet[r]; isSep && (r != utf8.RuneError || size > 1) {
+			out = append(out, segment{text: s[start:i], offset: start})
+			start = i + size
 		}
-		b.WriteRune(r)
+		i += size
 	}
-	out = append(out, b.String())
+	out = append(out, segment{text: s[start:], offset: start})
 	return out
 }
 
 // decode applies application/x-www-form-urlencoded rules.
 // When strict=false, returns lenient decoding: invalid percent sequences are left as literal characters.
-// When strict=true, errors from url.QueryUnescape are returned.
+// When strict=true, malformed escapes are returned as errors wrapping ErrInvalidPercent.
 func decode(s string, strict bool) (string, error) {
 	if !strict {
 		// Try fast path
@@ -145,14 +411,31 @@ func decode(s string, strict bool) (string, error) {
 	}
 	d, err := url.QueryUnescape(s)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%w: %w", ErrInvalidPercent, err)
 	}
 	return d, nil
 }
 
+// rawDecode is decode without '+' -> space, like PHP's rawurldecode (used for cookie values).
+func rawDecode(s string, strict bool) (string, error) {
+	d, err := url.PathUnescape(s)
+	if err == nil {
+		return d, nil
+	}
+	if strict {
+		return "", fmt.Errorf("%w: %w", ErrInvalidPercent, err)
+	}
+	return lenientUnescape(s, false), nil
+}
+
 // lenientDecode performs application/x-www-form-urlencoded decoding without failing on malformed escapes.
 // '+' -> space; valid %XX hex are decoded; invalid '%' sequences are kept literally.
 func lenientDecode(s string) string {
+	return lenientUnescape(s, true)
+}
+
+// lenientUnescape is lenientDecode with '+' -> space conversion only when plusAsSpace is set.
+func lenientUnescape(s string, plusAsSpace bool) string {
 	// Replace '+' first (per x-www-form-urlencoded semantics)
 	// We do this manually in the loop, to avoid double pass.
 	var out []byte
@@ -161,7 +444,11 @@ func lenientDecode(s string) string {
 		c := b[i]
 		switch c {
 		case '+':
-			out = append(out, ' ')
+			if plusAsSpace {
+				out = append(out, ' ')
+			} else {
+				out = append(out, '+')
+			}
 		case '%':
 			if i+2 < len(b) && isHex(b[i+1]) && isHex(b[i+2]) {
 				hx := string(b[i+1 : i+3])
@@ -200,13 +487,18 @@ func tokenizeKey(s string) []string {
 	}
 	var baseB strings.Builder
 	var tokens []string
+	unclosed := false // a '[' found no ']' after it, so no later '[' will either
 	for i := 0; i < len(s); {
 		c := s[i]
 		if c == '[' {
 			// search for the next ']'
-			j := i + 1
-			for j < len(s) && s[j] != ']' {
-				j++
+			j := len(s)
+			if !unclosed {
+				if k := strings.IndexByte(s[i+1:], ']'); k >= 0 {
+					j = i + 1 + k
+				} else {
+					unclosed = true
+				}
 			}
 			if j < len(s) {
 				// matched bracket pair -> emit token
@@ -236,6 +528,34 @@ func tokenizeKey(s string) []string {
 	return res
 }
 
+// mangleBase decodes the raw base of key the way PHP registers variable names:
+// - leading spaces are skipped (other surrounding whitespace is kept)
+// - ' ' and '.' become '_' in the part before the first '[' of the raw key
+// - the first unmatched '[' is already '_' (tokenizeKey); what follows it is kept verbatim, like PHP
+// Bracket tokens are not affected.
+func mangleBase(key, rawBase string, unescape func(string) (string, error)) (string, error) {
+	cut := strings.IndexByte(key, '[')
+	if cut < 0 || cut > len(rawBase) {
+		cut = len(rawBase)
+	}
+	head, err := unescape(rawBase[:cut])
+	if err != nil {
+		return "", err
+	}
+	tail, err := unescape(rawBase[cut:])
+	if err != nil {
+		return "", err
+	}
+	head = strings.TrimLeft(head, " ")
+	head = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '.' {
+			return '_'
+		}
+		return r
+	}, head)
+	return head + tail, nil
+}
+
 // insert updates root[base] following bracket tokens, creating containers as needed per rules.
 // Containers:
 // - Numeric tokens => ensure slice and set at index (expanding with nils)
@@ -244,11 +564,34 @@ func tokenizeKey(s string) []string {
 //
 // Mixed scalar/array/map resolution:
 // - If base doesn't exist, choose container by first token: "" or numeric => slice; non-numeric => map
-// - If base exists as string and first token is ""/numeric => convert to slice with the prior scalar as first element
-// - If base exists as string and first token is non-numeric => convert to map; prior scalar is discarded (mirrors PHP behavior for key[sub])
+// - If base exists as string (or Leaf) and first token is ""/numeric => convert to slice with the prior scalar as first element
+// - If base exists as string (or Leaf) and first token is non-numeric => convert to map; prior scalar is discarded (mirrors PHP behavior for key[sub])
 // - If base exists as slice/map, keep existing container type
-func insert(root map[string]any, base string, tokens []string, value string) {
+func insert(root map[string]any, base string, tokens []string, value any) {
+	insertReport(root, base, tokens, value, nil)
+}
+
+// insertReport is insert that also calls report, when not nil, for every value it overwrites
+// or discards; depth is the number of tokens leading to it (0 for the base variable).
+// It returns tokens with every "" replaced by the index the value was appended at.
+func insertReport(root map[string]any, base string, tokens []string, value any, report func(kind WarningKind, depth int, lost any)) (resolved []string) {
+	lose := func(kind WarningKind, depth int, lost any) {
+		if report != nil {
+			report(kind, depth, lost)
+		}
+	}
+	resolved = tokens
+	copied := false
+	appended := func(idx, at int) {
+		if !copied {
+			resolved, copied = append([]string(nil), tokens...), true
+		}
+		resolved[idx] = strconv.Itoa(at)
+	}
 	if len(tokens) == 0 {
+		if old, ok := root[base]; ok {
+			lose(overwriteKind(old), 0, old)
+		}
 		root[base] = value
 		return
 	}
@@ -271,13 +614,14 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 		setBase(current)
 	} else {
 		switch c := current.(type) {
-		case string:
+		case string, Leaf:
 			if first == "" || isNumeric(first) {
 				// Convert to slice, put prior scalar as first element
 				current = []any{c}
 				setBase(current)
 			} else {
 				// Convert to map, drop prior scalar (PHP: a=1 then a[b]=2 => a becomes array with b)
+				lose(WarnScalarDiscarded, 0, c)
 				current = make(map[string]any)
 				setBase(current)
 			}
@@ -287,6 +631,9 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 			current = c // keep map
 		default:
 			// unexpected type; replace according to first token for robustness
+			if c != nil {
+				lose(WarnScalarDiscarded, 0, c)
+			}
 			if first == "" || isNumeric(first) {
 				current = []any{}
 			} else {
@@ -315,6 +662,7 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 				sl := c
 				if isLeaf {
 					sl = append(sl, value)
+					appended(idx, len(sl)-1)
 					setCur(sl)
 					return
 				}
@@ -327,13 +675,16 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 				sl = append(sl, child)
 				setCur(sl)
 				idxInParent := len(sl) - 1
+				appended(idx, idxInParent)
 				cur = child
 				setCur = func(updated any) { sl[idxInParent] = updated }
 				continue
 			case map[string]any:
 				// Map hybrid append: choose next auto index key and set child/value under that string key
 				mp := c
-				key := strconv.Itoa(nextAutoIndex(mp))
+				at := nextAutoIndex(mp)
+				appended(idx, at)
+				key := strconv.Itoa(at)
 				if isLeaf {
 					mp[key] = value
 					setCur(mp)
@@ -352,10 +703,14 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 				continue
 			default:
 				// Unknown or nil: default to slice semantics for robustness
+				if cur != nil {
+					lose(WarnScalarDiscarded, idx, cur)
+				}
 				sl := ensureSlice(cur)
 				setCur(sl)
 				if isLeaf {
 					sl = append(sl, value)
+					appended(idx, len(sl)-1)
 					setCur(sl)
 					return
 				}
@@ -368,6 +723,7 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 				sl = append(sl, child)
 				setCur(sl)
 				idxInParent := len(sl) - 1
+				appended(idx, idxInParent)
 				cur = child
 				setCur = func(updated any) { sl[idxInParent] = updated }
 				continue
@@ -375,12 +731,27 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 		}
 
 		if isNumeric(tok) { // numeric index
+			if sl, ok := cur.([]any); ok && farIndex(sl, tok) {
+				// Growing the slice would allocate a hole per skipped index; PHP arrays have no
+				// holes, so keep the assigned elements under string keys and drop the holes.
+				mp := make(map[string]any, len(sl)+1)
+				for i, elem := range sl {
+					if elem != nil {
+						mp[strconv.Itoa(i)] = elem
+					}
+				}
+				setCur(mp)
+				cur = mp
+			}
 			// If current is a map, treat numeric token as a string key under the map (hybrid semantics).
 			switch c := cur.(type) {
 			case map[string]any:
 				mp := ensureMap(c)
 				setCur(mp)
 				if isLeaf {
+					if old, ok := mp[tok]; ok {
+						lose(overwriteKind(old), idx+1, old)
+					}
 					mp[tok] = value
 					setCur(mp)
 					return
@@ -398,6 +769,7 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 					case []any, map[string]any:
 						// OK
 					case string:
+						lose(WarnScalarDiscarded, idx+1, child)
 						if nextTok == "" || isNumeric(nextTok) {
 							child = []any{}
 						} else {
@@ -405,6 +777,7 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 						}
 						mp[tok] = child
 					default:
+						lose(WarnScalarDiscarded, idx+1, child)
 						if nextTok == "" || isNumeric(nextTok) {
 							child = []any{}
 						} else {
@@ -419,12 +792,18 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 				continue
 			default:
 				// Default behavior: ensure slice and set by numeric index
+				if _, ok := cur.([]any); !ok && cur != nil {
+					lose(WarnScalarDiscarded, idx, cur)
+				}
 				sl := ensureSlice(cur)
 				setCur(sl)
 				n, _ := strconv.Atoi(tok) // safe due to isNumeric
 				sl = growSlice(sl, n)
 				setCur(sl)
 				if isLeaf {
+					if sl[n] != nil {
+						lose(overwriteKind(sl[n]), idx+1, sl[n])
+					}
 					sl[n] = value
 					setCur(sl)
 					return
@@ -443,6 +822,7 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 					case []any, map[string]any:
 						// OK
 					case string:
+						lose(WarnScalarDiscarded, idx+1, child)
 						if nextTok == "" || isNumeric(nextTok) {
 							child = []any{}
 						} else {
@@ -451,6 +831,7 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 						sl[n] = child
 						setCur(sl)
 					default:
+						lose(WarnScalarDiscarded, idx+1, child)
 						if nextTok == "" || isNumeric(nextTok) {
 							child = []any{}
 						} else {
@@ -467,10 +848,16 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 		}
 
 		// non-numeric associative key => map
+		if !isContainer(cur) && cur != nil {
+			lose(WarnScalarDiscarded, idx, cur)
+		}
 		mp := ensureMap(cur)
 		setCur(mp)
 
 		if isLeaf {
+			if old, ok := mp[tok]; ok {
+				lose(overwriteKind(old), idx+1, old)
+			}
 			mp[tok] = value
 			// Map header doesn't need reassign (reference type), but keep consistent
 			setCur(mp)
@@ -490,6 +877,7 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 			case []any, map[string]any:
 				// OK
 			case string:
+				lose(WarnScalarDiscarded, idx+1, child)
 				if nextTok == "" || isNumeric(nextTok) {
 					child = []any{}
 				} else {
@@ -497,6 +885,7 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 				}
 				mp[tok] = child
 			default:
+				lose(WarnScalarDiscarded, idx+1, child)
 				if nextTok == "" || isNumeric(nextTok) {
 					child = []any{}
 				} else {
@@ -510,6 +899,7 @@ func insert(root map[string]any, base string, tokens []string, value string) {
 		cur = child
 		setCur = func(updated any) { mp[key] = updated }
 	}
+	return
 }
 
 // ensureSlice coerces container to []any. If it is a map, we replace it (robust resolution per tokens).
@@ -545,6 +935,16 @@ func ensureMap(container any) map[string]any {
 	}
 }
 
+// maxSliceHoles is the largest number of nil holes one index may add to a slice.
+const maxSliceHoles = 1024
+
+// farIndex reports whether the numeric token tok lies more than maxSliceHoles past the end
+// of sl, so that insert stores it in a map instead of growing sl.
+func farIndex(sl []any, tok string) bool {
+	n, err := strconv.Atoi(tok)
+	return err == nil && n-len(sl) > maxSliceHoles
+}
+
 // growSlice ensures sl has length > idx, expanding with nils.
 func growSlice(sl []any, idx int) []any {
 	if idx < 0 {
@@ -557,8 +957,18 @@ func growSlice(sl []any, idx int) []any {
 	return sl
 }
 
-// isNumeric reports whether the token is an unsigned integer consisting of digits only.
+// isNumeric reports whether the token is an unsigned integer consisting of digits only
+// that fits in an int. Longer digit runs are string keys, as in PHP.
 func isNumeric(s string) bool {
+	if !isDigits(s) {
+		return false
+	}
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// isDigits reports whether s is a non-empty run of decimal digits.
+func isDigits(s string) bool {
 	if s == "" {
 		return false
 	}
@@ -595,7 +1005,47 @@ func writeBack(child any, parent any) any {
 	return child
 }
 
-// Errors for potential future expansion
+// LimitError reports an input limit from Options that was exceeded while StrictLimits is set.
+type LimitError struct {
+	Limit  string // Options field name, e.g. "MaxInputVars"
+	Max    int    // configured limit
+	Offset int    // byte offset of the offending pair in the input
+	Pair   string // raw offending pair (empty for MaxTotalBytes)
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit %d exceeded at offset %d", e.Limit, e.Max, e.Offset)
+}
+
+// Unwrap lets callers match any limit with errors.Is(err, ErrLimitExceeded).
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// UTF8Error reports a pair whose key or value is not valid UTF-8 after decoding, under
+// InvalidUTF8Error.
+type UTF8Error struct {
+	Offset int    // byte offset of the offending pair in the input
+	Pair   string // raw offending pair
+	Part   string // "key" or "value"
+}
+
+func (e *UTF8Error) Error() string {
+	return fmt.Sprintf("malformed UTF-8 in %s at offset %d", e.Part, e.Offset)
+}
+
+// Unwrap lets callers match with errors.Is(err, ErrInvalidUTF8).
+func (e *UTF8Error) Unwrap() error {
+	return ErrInvalidUTF8
+}
+
 var (
-	ErrInvalidPercent = errors.New("invalid percent-escape")
+	ErrLimitExceeded   = errors.New("input limit exceeded")
+	ErrInvalidPercent  = errors.New("invalid percent-escape")
+	ErrUnsupportedType = errors.New("unsupported type")
+	ErrNotFound        = errors.New("path not found")
+
+	ErrMalformedPayload = errors.New("malformed serialized payload")
+	ErrObjectPayload    = errors.New("serialized objects and references not supported")
+	ErrInvalidUTF8      = errors.New("malformed UTF-8")
 )
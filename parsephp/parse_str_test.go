@@ -246,3 +246,14 @@ func TestExtraCloseBracketAfterMatchedTokenIgnored(t *testing.T) {
 		t.Fatalf("got %#v, want %#v", got, want)
 	}
 }
+
+func TestOverlongDigitIndexIsStringKey(t *testing.T) {
+	got, err := ParseStr("a[99999999999999999999]=x&a[1]=y")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"a": map[string]any{"99999999999999999999": "x", "1": "y"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package parsephp
+
+import "strconv"
+
+// Span is the half-open byte range [Start, End) of a part of the original input.
+type Span struct {
+	Start, End int
+}
+
+// Position locates the raw pair a leaf value came from.
+// Value is empty (Start == End == Pair.End) when the pair has no '='.
+type Position struct {
+	Pair  Span
+	Key   Span
+	Value Span
+}
+
+// Positions maps the leaves of a ParseStrWithPositions result to their source.
+type Positions struct {
+	root  map[string]any
+	spans spanNode // by base and tokens with appends resolved, e.g. a > b > 0
+}
+
+// spanNode is a trie of leaf positions keyed by decoded base and tokens, so that a key
+// holding brackets stays one step and the spans below a path go away with their node.
+type spanNode struct {
+	pos      Position
+	has      bool
+	children map[string]*spanNode
+}
+
+// node returns the node at path, creating the missing ones when create is set.
+func (n *spanNode) node(path []string, create bool) *spanNode {
+	for _, k := range path {
+		child := n.children[k]
+		if child == nil {
+			if !create {
+				return nil
+			}
+			if n.children == nil {
+				n.children = make(map[string]*spanNode)
+			}
+			child = &spanNode{}
+			n.children[k] = child
+		}
+		n = child
+	}
+	return n
+}
+
+// ParseStrWithPositions is ParseStrWithOptions that also records, for every leaf value,
+// where its raw pair, key and value are in query. The result is exactly the one of
+// ParseStrWithOptions; offsets count the leading '?' if there is one.
+func ParseStrWithPositions(query string, opts Options) (map[string]any, *Positions, error) {
+	pos := &Positions{root: make(map[string]any)}
+	w := &pairWalker{opts: opts}
+	w.b = &positionBuilder{pos: pos, w: w}
+	if err := w.walk(query, true); err != nil {
+		return nil, nil, err
+	}
+	return pos.root, pos, nil
+}
+
+// Lookup returns the position of the leaf at path, written in bracket notation with
+// decoded keys and explicit indices, e.g. "filter[price][0]". ok is false when path
+// does not name a leaf of the result, including leaves that were later overwritten by
+// a container. Keys containing ']' cannot be written this way; use LookupTokens.
+func (p *Positions) Lookup(path string) (pos Position, ok bool) {
+	seq := tokenizeKey(path)
+	if len(seq) == 0 {
+		return Position{}, false
+	}
+	return p.LookupTokens(seq[0], seq[1:]...)
+}
+
+// LookupTokens is Lookup with the path given as its decoded base and bracket tokens,
+// e.g. LookupTokens("a", "]") for the leaf of the pair a[%5D]=x.
+func (p *Positions) LookupTokens(base string, tokens ...string) (pos Position, ok bool) {
+	canon, v, ok := resolvePath(p.root, base, tokens)
+	if !ok || v == nil || isContainer(v) {
+		return Position{}, false
+	}
+	n := p.spans.node(append([]string{base}, canon...), false)
+	if n == nil || !n.has {
+		return Position{}, false
+	}
+	return n.pos, true
+}
+
+// resolvePath returns the value of root named by base and tokens together with the tokens
+// in canonical form (insert reads a[01] as a[1]), and whether the path exists.
+func resolvePath(root map[string]any, base string, tokens []string) ([]string, any, bool) {
+	cur, ok := root[base]
+	canon := make([]string, len(tokens))
+	for i, tok := range tokens {
+		if !ok {
+			return nil, nil, false
+		}
+		switch c := cur.(type) {
+		case map[string]any:
+			cur, ok = c[tok]
+			canon[i] = tok
+		case []any:
+			n, err := strconv.Atoi(tok)
+			if !isNumeric(tok) || err != nil || n >= len(c) {
+				return nil, nil, false
+			}
+			cur = c[n]
+			canon[i] = strconv.Itoa(n)
+		default:
+			return nil, nil, false
+		}
+	}
+	if !ok {
+		return nil, nil, false
+	}
+	return canon, cur, true
+}
+
+// positionBuilder is mapBuilder recording the span of the current pair for every leaf.
+type positionBuilder struct {
+	pos *Positions
+	w   *pairWalker
+}
+
+func (b *positionBuilder) insert(base string, tokens []string, value any) {
+	root, spans := b.pos.root, &b.pos.spans
+	if old, ok := root[base]; ok && !isContainer(old) && len(tokens) > 0 && (tokens[0] == "" || isNumeric(tokens[0])) {
+		// insert promotes a scalar base to the first element of a list
+		if n := spans.node([]string{base}, false); n != nil && n.has {
+			first := spans.node([]string{base, "0"}, true)
+			first.pos, first.has = n.pos, true
+		}
+	}
+
+	resolved := insertReport(root, base, tokens, value, nil)
+	canon, v, ok := resolvePath(root, base, resolved)
+	if !ok || v == nil || isContainer(v) {
+		return
+	}
+	path := append([]string{base}, canon...)
+	// the containers on the way may have replaced leaves, the leaf may have replaced a container
+	n := spans
+	for _, k := range path {
+		n.has = false
+		n = n.node([]string{k}, true)
+	}
+	n.children = nil
+
+	at := b.w.at
+	k, _, hasEq := splitPair(at.Pair)
+	end := at.Offset + len(at.Pair)
+	p := Position{
+		Pair:  Span{at.Offset, end},
+		Key:   Span{at.Offset, at.Offset + len(k)},
+		Value: Span{end, end},
+	}
+	if hasEq {
+		p.Value.Start = p.Key.End + 1
+	}
+	n.pos, n.has = p, true
+}
+
+func (b *positionBuilder) remove(base string) {
+	delete(b.pos.root, base)
+	delete(b.pos.spans.children, base)
+}
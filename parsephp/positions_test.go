@@ -0,0 +1,104 @@
+package parsephp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStrWithPositions(t *testing.T) {
+	in := "?filter[price][]=10&filter[price][]=%32%30&flag&a=1&a=2&b[x]=1&b[x][y]=2"
+	got, pos, err := ParseStrWithPositions(in, DefaultOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, _ := ParseStr(in)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("result differs from ParseStr: got %#v, want %#v", got, want)
+	}
+
+	tests := []struct {
+		path             string
+		pair, key, value string
+	}{
+		{"filter[price][0]", "filter[price][]=10", "filter[price][]", "10"},
+		{"filter[price][1]", "filter[price][]=%32%30", "filter[price][]", "%32%30"},
+		{"flag", "flag", "flag", ""},
+		{"a", "a=2", "a", "2"},
+		{"b[x][y]", "b[x][y]=2", "b[x][y]", "2"},
+	}
+	for _, tt := range tests {
+		p, ok := pos.Lookup(tt.path)
+		if !ok {
+			t.Fatalf("%s: not found", tt.path)
+		}
+		text := func(s Span) string { return in[s.Start:s.End] }
+		if text(p.Pair) != tt.pair || text(p.Key) != tt.key || text(p.Value) != tt.value {
+			t.Fatalf("%s: got pair %q key %q value %q", tt.path, text(p.Pair), text(p.Key), text(p.Value))
+		}
+	}
+
+	for _, path := range []string{"b[x]", "filter[price]", "filter[price][2]", "missing"} {
+		if _, ok := pos.Lookup(path); ok {
+			t.Fatalf("%s: found, want no leaf", path)
+		}
+	}
+}
+
+func TestParseStrWithPositions_OverwriteAndPromotion(t *testing.T) {
+	tests := []struct {
+		in    string
+		path  string
+		pair  string
+		stale []string
+	}{
+		{"a[0]=x&a=y&a[]=z", "a[0]", "a=y", nil},
+		{"a[0]=x&a=y&a[]=z", "a[1]", "a[]=z", []string{"a"}},
+		{"a=1&a[]=2", "a[0]", "a=1", []string{"a"}},
+		{"a=1&a[]=2", "a[1]", "a[]=2", nil},
+		{"a[b][c]=x&a[b]=y", "a[b]", "a[b]=y", []string{"a[b][c]"}},
+		{"a[b]=x&a[b][c]=y", "a[b][c]", "a[b][c]=y", []string{"a[b]"}},
+	}
+	for _, tt := range tests {
+		_, pos, err := ParseStrWithPositions(tt.in, DefaultOptions)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.in, err)
+		}
+		p, ok := pos.Lookup(tt.path)
+		if !ok {
+			t.Fatalf("%q: %s not found", tt.in, tt.path)
+		}
+		if got := tt.in[p.Pair.Start:p.Pair.End]; got != tt.pair {
+			t.Fatalf("%q: %s: got pair %q, want %q", tt.in, tt.path, got, tt.pair)
+		}
+		for _, key := range tt.stale {
+			seq := tokenizeKey(key)
+			if n := pos.spans.node(seq, false); n != nil && n.has {
+				t.Fatalf("%q: stale span kept for %s", tt.in, key)
+			}
+		}
+	}
+}
+
+func TestParseStrWithPositions_RepeatedOverwritesAndBracketKeys(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&sb, "a=%d&a[x][%d]=y&", i, i)
+	}
+	in := sb.String() + "a[%5D]=z"
+	_, pos, err := ParseStrWithPositions(in, DefaultOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := pos.spans.node([]string{"a", "x"}, false); n == nil || len(n.children) != 1 {
+		t.Fatalf("got %+v, want only the last a[x] leaf", n)
+	}
+	p, ok := pos.LookupTokens("a", "]")
+	if !ok || in[p.Pair.Start:p.Pair.End] != "a[%5D]=z" {
+		t.Fatalf("a[%%5D]: got %+v, %v", p, ok)
+	}
+	if _, ok := pos.LookupTokens("a", "x", "1999"); !ok {
+		t.Fatalf("a[x][1999]: not found")
+	}
+}
@@ -0,0 +1,151 @@
+package parsephp
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// ParseReader is like ParseStrWithOptions but scans the query from r, e.g. an
+// application/x-www-form-urlencoded request body, without reading it into memory first.
+//
+// Pairs are buffered one at a time. With MaxKeyLength/MaxValueLength set, an oversized pair is
+// dropped (or reported) as soon as it crosses the limit and is skipped without being buffered,
+// so memory stays bounded by those limits. Reading stops early once MaxInputVars or
+// MaxTotalBytes is exceeded; an exceeded MaxTotalBytes yields an empty result, or a *LimitError
+// with StrictLimits, exactly like ParseStrWithOptions.
+func ParseReader(r io.Reader, opts Options) (map[string]any, error) {
+	root := make(map[string]any)
+	exceeded, err := scanPairs(r, opts, mapBuilder(root))
+	if err != nil {
+		return nil, err
+	}
+	if exceeded {
+		return make(map[string]any), nil
+	}
+	return root, nil
+}
+
+// scanPairs is the io.Reader counterpart of walkPairs. It reports whether MaxTotalBytes was
+// exceeded without StrictLimits, in which case the caller must discard what b received.
+func scanPairs(r io.Reader, opts Options, b builder) (bool, error) {
+	if len(opts.Separators) == 0 {
+		opts.Separators = DefaultOptions.Separators
+	}
+	seps := make([][]byte, 0, len(opts.Separators))
+	slack := 0 // bytes at the end of buf that may still turn out to be a separator
+	for _, sep := range opts.Separators {
+		enc := []byte(string(sep))
+		seps = append(seps, enc)
+		if len(enc)-1 > slack {
+			slack = len(enc) - 1
+		}
+	}
+
+	br := bufio.NewReader(r)
+	w := &pairWalker{opts: opts, b: b}
+	var buf []byte
+	read := 0     // bytes consumed so far
+	start := 0    // offset of the pair being buffered
+	eq := -1      // index of the first '=' in buf
+	skip := false // the current pair was dropped; discard bytes up to the next separator
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		read++
+		if opts.MaxTotalBytes > 0 && read > opts.MaxTotalBytes {
+			// PHP discards the whole input when it exceeds post_max_size
+			if opts.StrictLimits {
+				return false, &LimitError{Limit: "MaxTotalBytes", Max: opts.MaxTotalBytes, Offset: opts.MaxTotalBytes}
+			}
+			return true, nil
+		}
+		if read == 1 && c == '?' {
+			// Trim optional leading '?'
+			start = 1
+			continue
+		}
+
+		buf = append(buf, c)
+		if len(buf) == slack+1 && !skip && opts.MaxInputVars > 0 && w.vars >= opts.MaxInputVars {
+			// a new pair starts past max_input_vars: stop before buffering it
+			if n := sepSuffix(buf, seps); n == 0 || n < len(buf) {
+				return false, w.count(string(buf), start)
+			}
+		}
+		if n := sepSuffix(buf, seps); n > 0 {
+			if !skip {
+				if err := w.pair(string(buf[:len(buf)-n]), start); err != nil {
+					return false, err
+				}
+				if w.stopped {
+					return false, nil
+				}
+			}
+			buf, eq, skip, start = buf[:0], -1, false, read
+			continue
+		}
+		if skip {
+			// keep only what may still complete a separator
+			if len(buf) > slack {
+				buf = append(buf[:0], buf[len(buf)-slack:]...)
+			}
+			continue
+		}
+		if c == '=' && eq < 0 {
+			eq = len(buf) - 1
+		}
+
+		limit, max := "", 0
+		switch {
+		case eq < 0 && opts.MaxKeyLength > 0 && len(buf)-slack > opts.MaxKeyLength:
+			limit, max = "MaxKeyLength", opts.MaxKeyLength
+		case eq >= 0 && opts.MaxValueLength > 0 && len(buf)-eq-1-slack > opts.MaxValueLength:
+			limit, max = "MaxValueLength", opts.MaxValueLength
+		}
+		if limit != "" {
+			// the pair is over a length limit: count it, report or drop it, then skip the rest
+			raw := string(buf)
+			if err := w.count(raw, start); err != nil || w.stopped {
+				return false, err
+			}
+			if err := w.limitHit(limit, max, start, raw); err != nil {
+				return false, err
+			}
+			skip = true
+			buf = buf[:0]
+		}
+	}
+	if !skip {
+		if err := w.pair(string(buf), start); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// sepSuffix returns the length of the separator buf ends with, or 0.
+func sepSuffix(buf []byte, seps [][]byte) int {
+	last := buf[len(buf)-1]
+	for _, sep := range seps {
+		if len(sep) == 1 {
+			if last == sep[0] {
+				return 1
+			}
+			continue
+		}
+		if last < utf8.RuneSelf {
+			continue // multi-byte separators never end in an ASCII byte
+		}
+		if bytes.HasSuffix(buf, sep) {
+			return len(sep)
+		}
+	}
+	return 0
+}
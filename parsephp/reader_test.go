@@ -0,0 +1,102 @@
+package parsephp
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseReader_MatchesParseStr(t *testing.T) {
+	inputs := []string{
+		"?x=1&y=2&",
+		"a[]=b&a[]=c;a[5]=d&&flag",
+		"a[b][c]=d&a[][d]=c&q=%2B+%2520&bad=%ZZ",
+		"城市=北京&k=%E4%B8%AD%E6%96%87",
+		"",
+		"?",
+	}
+	for _, in := range inputs {
+		want, err := ParseStr(in)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", in, err)
+		}
+		got, err := ParseReader(strings.NewReader(in), DefaultOptions)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", in, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q: got %#v, want %#v", in, got, want)
+		}
+	}
+}
+
+func TestParseReader_MultiByteSeparator(t *testing.T) {
+	opts := Options{Separators: []rune{'；', '&'}}
+	got, err := ParseReader(strings.NewReader("a=中；b=1&c=2"), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]any{"a": "中", "b": "1", "c": "2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParseReader_LengthLimitsSkipOversizedPairs(t *testing.T) {
+	in := "a=" + strings.Repeat("x", 100) + "&" + strings.Repeat("k", 50) + "=1&b=2"
+	opts := Options{MaxKeyLength: 8, MaxValueLength: 8}
+	got, err := ParseReader(strings.NewReader(in), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]any{"b": "2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+
+	opts.StrictLimits = true
+	_, err = ParseReader(strings.NewReader(in), opts)
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != "MaxValueLength" || le.Offset != 0 {
+		t.Fatalf("got %v, want MaxValueLength at offset 0", err)
+	}
+}
+
+// endless yields 'x' forever.
+type endless struct{}
+
+func (endless) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'x'
+	}
+	return len(p), nil
+}
+
+func TestParseReader_StopsEarlyOnTotalBytes(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("a=1&b="), endless{})
+	_, err := ParseReader(r, Options{MaxTotalBytes: 1 << 16, StrictLimits: true})
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != "MaxTotalBytes" {
+		t.Fatalf("got %v, want MaxTotalBytes limit", err)
+	}
+
+	got, err := ParseReader(io.MultiReader(strings.NewReader("a=1&b="), endless{}), Options{MaxTotalBytes: 1 << 16})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got %#v, want empty result", got)
+	}
+}
+
+func TestParseReader_StopsAfterMaxInputVars(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("a=1&b=2&c="), endless{})
+	got, err := ParseReader(r, Options{MaxInputVars: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]any{"a": "1", "b": "2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,173 @@
+package parsephp
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RequestData holds the PHP superglobal equivalents of an HTTP request.
+type RequestData struct {
+	Get     map[string]any  // $_GET: the URL query
+	Post    map[string]any  // $_POST: the form body
+	Files   map[string]any  // $_FILES: file fields of a multipart body
+	Cookie  map[string]any  // $_COOKIE: the Cookie headers
+	Uploads []*UploadedFile // the files in Files, see MultipartForm
+	Request map[string]any  // $_REQUEST: the sources of RequestOrder merged in order
+}
+
+// ParseRequest parses r the way PHP fills $_GET, $_POST and $_REQUEST.
+//
+// The URL query is always parsed. The body is parsed for POST, PUT and PATCH requests
+// (like net/http's ParseForm) when its Content-Type is application/x-www-form-urlencoded
+// (through ParseReader) or multipart/form-data (through ParseMultipart, which also fills
+// Files and Uploads); other bodies are left unread and Post is empty. The body is capped
+// at MaxBodyBytes and consumed by this call. Callers own the uploaded temp files and should
+// remove them (MultipartForm.RemoveAll) when done.
+//
+// Cookie headers are parsed with ParseCookie using the same Options.
+//
+// Request merges the sources named in RequestOrder like PHP: a later source overwrites an
+// earlier one key by key, recursing only where both sides hold arrays.
+func ParseRequest(r *http.Request, opts RequestOptions) (*RequestData, error) {
+	if opts.RequestOrder == "" {
+		opts.RequestOrder = DefaultRequestOptions.RequestOrder
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = DefaultRequestOptions.MaxBodyBytes
+	}
+
+	get, err := ParseStrWithOptions(r.URL.RawQuery, opts.Options)
+	if err != nil {
+		return nil, fmt.Errorf("parse request query: %w", err)
+	}
+	cookie, err := ParseCookie(strings.Join(r.Header.Values("Cookie"), "; "), CookieOptions{Options: opts.Options})
+	if err != nil {
+		return nil, fmt.Errorf("parse request cookies: %w", err)
+	}
+	data := &RequestData{Get: get, Post: make(map[string]any), Files: make(map[string]any), Cookie: cookie, Request: make(map[string]any)}
+	if ct := r.Header.Get("Content-Type"); ct != "" && hasFormBody(r) {
+		mediaType, params, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return nil, fmt.Errorf("parse request content type %q: %w", ct, err)
+		}
+		body := http.MaxBytesReader(nil, r.Body, opts.MaxBodyBytes)
+		switch mediaType {
+		case "application/x-www-form-urlencoded":
+			data.Post, err = ParseReader(body, opts.Options)
+			if err != nil {
+				return nil, fmt.Errorf("parse request body: %w", err)
+			}
+		case "multipart/form-data":
+			if params["boundary"] == "" {
+				return nil, fmt.Errorf("parse request body: multipart/form-data without boundary")
+			}
+			form, err := ParseMultipart(body, params["boundary"], opts.Options, opts.Uploads)
+			if err != nil {
+				return nil, fmt.Errorf("parse request body: %w", err)
+			}
+			data.Post, data.Files, data.Uploads = form.Post, form.Files, form.Uploads
+		}
+	}
+
+	for _, src := range strings.ToUpper(opts.RequestOrder) {
+		switch src {
+		case 'G':
+			mergeReplace(data.Request, data.Get)
+		case 'P':
+			mergeReplace(data.Request, data.Post)
+		case 'C':
+			mergeReplace(data.Request, data.Cookie)
+		}
+	}
+	return data, nil
+}
+
+// hasFormBody reports whether r may carry a form body that should be parsed.
+func hasFormBody(r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody {
+		return false
+	}
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+// mergeReplace merges src into the top-level map dst like PHP's php_autoglobal_merge:
+// each key of src overwrites dst's, except that two arrays are merged recursively.
+// Nothing of src is shared with dst.
+func mergeReplace(dst, src map[string]any) {
+	for k, v := range src {
+		dst[k] = replaceRecursive(dst[k], v)
+	}
+}
+
+// replaceRecursive returns dst with src applied on top of it, recursing where both are containers.
+// Two slices stay a slice (index by index); otherwise the result is a map, with slice indices as
+// string keys like ensureMap. nil holes in src are not keys and leave dst untouched.
+func replaceRecursive(dst, src any) any {
+	if !isContainer(dst) || !isContainer(src) {
+		return cloneTree(src)
+	}
+	if ds, ok := dst.([]any); ok {
+		if ss, ok := src.([]any); ok {
+			out := make([]any, len(ds))
+			copy(out, ds)
+			for i, v := range ss {
+				if v == nil {
+					continue
+				}
+				out = growSlice(out, i)
+				out[i] = replaceRecursive(out[i], v)
+			}
+			return out
+		}
+	}
+	out := cloneTree(ensureMap(dst)).(map[string]any)
+	switch s := src.(type) {
+	case map[string]any:
+		for k, v := range s {
+			out[k] = replaceRecursive(out[k], v)
+		}
+	case []any:
+		for i, v := range s {
+			if v == nil {
+				continue
+			}
+			k := strconv.Itoa(i)
+			out[k] = replaceRecursive(out[k], v)
+		}
+	}
+	return out
+}
+
+func isContainer(v any) bool {
+	switch v.(type) {
+	case map[string]any, []any:
+		return true
+	}
+	return false
+}
+
+// cloneTree deep-copies a map[string]any/[]any tree; leaves are shared as they are immutable.
+func cloneTree(v any) any {
+	switch c := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(c))
+		for k, elem := range c {
+			m[k] = cloneTree(elem)
+		}
+		return m
+	case []any:
+		sl := make([]any, len(c))
+		for i, elem := range c {
+			sl[i] = cloneTree(elem)
+		}
+		return sl
+	}
+	return v
+}
@@ -0,0 +1,101 @@
+package parsephp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newFormRequest(method, target, body, contentType string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func TestParseRequest_GetPostAndMergeOrder(t *testing.T) {
+	body := "a=post&arr[x]=px&arr[]=p0&only_post=1"
+	r := newFormRequest(http.MethodPost, "/?a=get&arr[x]=gx&arr[y]=gy&only_get=1", body, "application/x-www-form-urlencoded; charset=UTF-8")
+	got, err := ParseRequest(r, DefaultRequestOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantGet := map[string]any{"a": "get", "arr": map[string]any{"x": "gx", "y": "gy"}, "only_get": "1"}
+	wantPost := map[string]any{"a": "post", "arr": map[string]any{"x": "px", "0": "p0"}, "only_post": "1"}
+	wantReq := map[string]any{
+		"a":         "post",
+		"arr":       map[string]any{"x": "px", "y": "gy", "0": "p0"},
+		"only_get":  "1",
+		"only_post": "1",
+	}
+	if !reflect.DeepEqual(got.Get, wantGet) {
+		t.Fatalf("get: got %#v, want %#v", got.Get, wantGet)
+	}
+	if !reflect.DeepEqual(got.Post, wantPost) {
+		t.Fatalf("post: got %#v, want %#v", got.Post, wantPost)
+	}
+	if !reflect.DeepEqual(got.Request, wantReq) {
+		t.Fatalf("request: got %#v, want %#v", got.Request, wantReq)
+	}
+
+	r = newFormRequest(http.MethodPost, "/?a=get", "a=post", "application/x-www-form-urlencoded")
+	got, err = ParseRequest(r, RequestOptions{RequestOrder: "PG"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Request["a"] != "get" {
+		t.Fatalf("request order PG: got %#v, want 'get'", got.Request["a"])
+	}
+}
+
+func TestParseRequest_MergeSlicesByIndex(t *testing.T) {
+	r := newFormRequest(http.MethodPost, "/?l[]=a&l[]=b&l[]=c", "l[1]=B", "application/x-www-form-urlencoded")
+	got, err := ParseRequest(r, DefaultRequestOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []any{"a", "B", "c"}
+	if !reflect.DeepEqual(got.Request["l"], want) {
+		t.Fatalf("got %#v, want %#v", got.Request["l"], want)
+	}
+	// the merge must not alias the sources
+	got.Request["l"].([]any)[0] = "changed"
+	if got.Get["l"].([]any)[0] != "a" {
+		t.Fatalf("Request shares storage with Get")
+	}
+}
+
+func TestParseRequest_BodySkippedOrCapped(t *testing.T) {
+	cases := []struct {
+		name, method, ct string
+	}{
+		{"json_body", http.MethodPost, "application/json"},
+		{"get_method", http.MethodGet, "application/x-www-form-urlencoded"},
+		{"no_content_type", http.MethodPost, ""},
+	}
+	for _, c := range cases {
+		got, err := ParseRequest(newFormRequest(c.method, "/", "a=1", c.ct), DefaultRequestOptions)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if len(got.Post) != 0 {
+			t.Fatalf("%s: got post %#v, want empty", c.name, got.Post)
+		}
+	}
+
+	r := newFormRequest(http.MethodPost, "/", "a="+strings.Repeat("x", 100), "application/x-www-form-urlencoded")
+	_, err := ParseRequest(r, RequestOptions{MaxBodyBytes: 32})
+	var mbe *http.MaxBytesError
+	if !errors.As(err, &mbe) {
+		t.Fatalf("got %v, want *http.MaxBytesError", err)
+	}
+
+	r = newFormRequest(http.MethodPost, "/", "a=1", "text/;;")
+	if _, err := ParseRequest(r, DefaultRequestOptions); err == nil {
+		t.Fatalf("expected error for malformed content type")
+	}
+}
@@ -0,0 +1,328 @@
+package parsephp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Serialize encodes v like PHP's serialize(), e.g. a:1:{s:1:"a";a:1:{i:0;s:1:"x";}}.
+// v is a ParseStr-style tree (see FormatPrintR); entries are written in the order
+// HTTPBuildQuery uses and keys PHP would store as integers are written as i:N; nil slice
+// holes are skipped like HTTPBuildQuery does, so a[1]=x gives a:1:{i:1;s:1:"x";}.
+// Unsupported leaves yield an error wrapping ErrUnsupportedType.
+func Serialize(v any) (string, error) {
+	var sb strings.Builder
+	if err := serialize(&sb, v, nil); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func serialize(sb *strings.Builder, v any, path []string) error {
+	if entries, ok := containerEntries(v, len(path) == 0); ok {
+		entries = skipHoles(entries)
+		sb.WriteString("a:" + strconv.Itoa(len(entries)) + ":{")
+		for _, e := range entries {
+			if e.isInt {
+				sb.WriteString("i:" + e.key + ";")
+			} else {
+				serializeString(sb, e.key)
+			}
+			if err := serialize(sb, e.value, append(path, e.key)); err != nil {
+				return err
+			}
+		}
+		sb.WriteString("}")
+		return nil
+	}
+	typ, s, err := phpLeaf("serialize", path, v)
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case "NULL":
+		sb.WriteString("N;")
+	case "bool":
+		if s == "true" {
+			sb.WriteString("b:1;")
+		} else {
+			sb.WriteString("b:0;")
+		}
+	case "int":
+		sb.WriteString("i:" + s + ";")
+	case "float":
+		f, _ := strconv.ParseFloat(s, 64)
+		sb.WriteString("d:" + formatFloat(f) + ";")
+	default:
+		serializeString(sb, s)
+	}
+	return nil
+}
+
+func serializeString(sb *strings.Builder, s string) {
+	sb.WriteString("s:" + strconv.Itoa(len(s)) + ":\"" + s + "\";")
+}
+
+// UnserializeError reports a payload Unserialize cannot decode.
+type UnserializeError struct {
+	Offset int    // byte offset in the payload
+	Msg    string // what was wrong there
+	Err    error  // ErrMalformedPayload, ErrObjectPayload or ErrLimitExceeded
+}
+
+func (e *UnserializeError) Error() string {
+	return fmt.Sprintf("unserialize: %s at offset %d", e.Msg, e.Offset)
+}
+
+// Unwrap lets callers classify the failure with errors.Is.
+func (e *UnserializeError) Unwrap() error {
+	return e.Err
+}
+
+// Unserialize decodes a PHP serialize() payload with DefaultUnserializeOptions.
+func Unserialize(s string) (any, error) {
+	return UnserializeWithOptions(s, DefaultUnserializeOptions)
+}
+
+// UnserializeWithOptions decodes a PHP serialize() payload into the trees ParseStr produces.
+// Arrays whose keys are 0..n-1 in order become []any, other arrays map[string]any with
+// integer keys in decimal; strings, integers (int), floats (float64), booleans and NULL
+// (nil) are the leaves. Objects, enums and references are refused with ErrObjectPayload,
+// anything else that is not exactly one valid value with ErrMalformedPayload; both come
+// as an *UnserializeError.
+func UnserializeWithOptions(s string, opts UnserializeOptions) (any, error) {
+	if opts.MaxBytes > 0 && len(s) > opts.MaxBytes {
+		return nil, &UnserializeError{Offset: opts.MaxBytes, Msg: fmt.Sprintf("payload larger than %d bytes", opts.MaxBytes), Err: ErrLimitExceeded}
+	}
+	d := &unserializer{s: s, opts: opts}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(s) {
+		return nil, d.fail("trailing data")
+	}
+	return v, nil
+}
+
+// unserializer is a cursor over a serialize() payload.
+type unserializer struct {
+	s    string
+	pos  int
+	opts UnserializeOptions
+}
+
+func (d *unserializer) fail(msg string) error {
+	return &UnserializeError{Offset: d.pos, Msg: msg, Err: ErrMalformedPayload}
+}
+
+// expect consumes lit or fails.
+func (d *unserializer) expect(lit string) error {
+	if !strings.HasPrefix(d.s[d.pos:], lit) {
+		return d.fail(fmt.Sprintf("expected %q", lit))
+	}
+	d.pos += len(lit)
+	return nil
+}
+
+// until returns the text up to the next stop byte and consumes both.
+func (d *unserializer) until(stop byte) (string, error) {
+	i := strings.IndexByte(d.s[d.pos:], stop)
+	if i < 0 {
+		return "", d.fail(fmt.Sprintf("missing %q", stop))
+	}
+	text := d.s[d.pos : d.pos+i]
+	d.pos += i + 1
+	return text, nil
+}
+
+// value decodes the value at the cursor; depth is the number of enclosing arrays.
+func (d *unserializer) value(depth int) (any, error) {
+	if d.pos+1 >= len(d.s) {
+		return nil, d.fail("unexpected end of payload")
+	}
+	start := d.pos
+	tag := d.s[d.pos]
+	if tag == 'N' {
+		d.pos++
+		return nil, d.expect(";")
+	}
+	switch tag {
+	case 'O', 'C', 'E', 'r', 'R':
+		return nil, &UnserializeError{Offset: start, Msg: fmt.Sprintf("unsupported type %q", tag), Err: ErrObjectPayload}
+	}
+	d.pos++
+	if err := d.expect(":"); err != nil {
+		return nil, err
+	}
+	switch tag {
+	case 'b':
+		text, err := d.until(';')
+		if err != nil {
+			return nil, err
+		}
+		if text != "0" && text != "1" {
+			return nil, &UnserializeError{Offset: start, Msg: "invalid boolean", Err: ErrMalformedPayload}
+		}
+		return text == "1", nil
+	case 'i':
+		text, err := d.until(';')
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(text)
+		if err != nil || !isSignedDigits(text) {
+			return nil, &UnserializeError{Offset: start, Msg: "invalid integer", Err: ErrMalformedPayload}
+		}
+		return n, nil
+	case 'd':
+		text, err := d.until(';')
+		if err != nil {
+			return nil, err
+		}
+		f, ok := parsePHPFloat(text)
+		if !ok {
+			return nil, &UnserializeError{Offset: start, Msg: "invalid float", Err: ErrMalformedPayload}
+		}
+		return f, nil
+	case 's':
+		return d.str(start)
+	case 'a':
+		return d.array(start, depth+1)
+	}
+	return nil, &UnserializeError{Offset: start, Msg: fmt.Sprintf("unknown type %q", tag), Err: ErrMalformedPayload}
+}
+
+// length reads the "N:" of a string or array.
+func (d *unserializer) length(start int) (int, error) {
+	text, err := d.until(':')
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n < 0 || !isSignedDigits(text) || text[0] == '-' || text[0] == '+' {
+		return 0, &UnserializeError{Offset: start, Msg: "invalid length", Err: ErrMalformedPayload}
+	}
+	return n, nil
+}
+
+// str decodes the rest of s:N:"...";.
+func (d *unserializer) str(start int) (string, error) {
+	n, err := d.length(start)
+	if err != nil {
+		return "", err
+	}
+	if err := d.expect(`"`); err != nil {
+		return "", err
+	}
+	if n > len(d.s)-d.pos {
+		return "", &UnserializeError{Offset: start, Msg: "string length beyond end of payload", Err: ErrMalformedPayload}
+	}
+	text := d.s[d.pos : d.pos+n]
+	d.pos += n
+	if err := d.expect(`";`); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// array decodes the rest of a:N:{...} at nesting depth.
+func (d *unserializer) array(start, depth int) (any, error) {
+	if d.opts.MaxDepth > 0 && depth > d.opts.MaxDepth {
+		return nil, &UnserializeError{Offset: start, Msg: fmt.Sprintf("arrays nested deeper than %d", d.opts.MaxDepth), Err: ErrLimitExceeded}
+	}
+	n, err := d.length(start)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.expect("{"); err != nil {
+		return nil, err
+	}
+	// every element takes at least 4 bytes ("i:0;N;" is 6), so never trust n for allocation
+	keys := make([]Key, 0, min(n, (len(d.s)-d.pos)/4))
+	values := make(map[Key]any, cap(keys))
+	for i := 0; i < n; i++ {
+		var k Key
+		keyStart := d.pos
+		switch {
+		case strings.HasPrefix(d.s[d.pos:], "i:"):
+			v, err := d.value(depth)
+			if err != nil {
+				return nil, err
+			}
+			k = IntKey(v.(int))
+		case strings.HasPrefix(d.s[d.pos:], "s:"):
+			d.pos += 2
+			s, err := d.str(keyStart)
+			if err != nil {
+				return nil, err
+			}
+			k = StringKey(s)
+		default:
+			return nil, d.fail("array key must be an integer or a string")
+		}
+		v, err := d.value(depth)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := values[k]; !dup {
+			keys = append(keys, k)
+		}
+		values[k] = v // a repeated key overwrites, like PHP
+	}
+	if err := d.expect("}"); err != nil {
+		return nil, err
+	}
+
+	isList := true
+	for i, k := range keys {
+		if !k.IsInt() || k.Int() != i {
+			isList = false
+			break
+		}
+	}
+	if isList {
+		out := make([]any, len(keys))
+		for i, k := range keys {
+			out[i] = values[k]
+		}
+		return out, nil
+	}
+	out := make(map[string]any, len(keys))
+	for _, k := range keys {
+		out[k.String()] = values[k]
+	}
+	return out, nil
+}
+
+// isSignedDigits reports whether s is an optional sign followed by decimal digits.
+func isSignedDigits(s string) bool {
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	return isDigits(s)
+}
+
+// parsePHPFloat parses the float syntax of serialize(): decimal with optional exponent,
+// INF, -INF or NAN.
+func parsePHPFloat(s string) (float64, bool) {
+	switch s {
+	case "INF":
+		return math.Inf(1), true
+	case "-INF":
+		return math.Inf(-1), true
+	case "NAN":
+		return math.NaN(), true
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-') {
+			return 0, false
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil || errors.Is(err, strconv.ErrRange) // out of range gives ±INF or 0 like PHP
+}
@@ -0,0 +1,127 @@
+package parsephp
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSerialize(t *testing.T) {
+	v := map[string]any{
+		"a": []any{"x", nil, "é"},
+		"b": map[string]any{"k": "1", "7": "y"},
+	}
+	got, err := Serialize(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `a:2:{s:1:"a";a:2:{i:0;s:1:"x";i:2;s:2:"é";}s:1:"b";a:2:{s:1:"k";s:1:"1";i:7;s:1:"y";}}`
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	scalars, err := Serialize([]any{true, 3, 1.5, 1e25})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "a:4:{i:0;b:1;i:1;i:3;i:2;d:1.5;i:3;d:1.0E+25;}"; scalars != want {
+		t.Fatalf("got %s, want %s", scalars, want)
+	}
+
+	if _, err := Serialize(map[string]any{"f": func() {}}); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("got %v, want ErrUnsupportedType", err)
+	}
+}
+
+func TestUnserialize_RoundTripsParseStr(t *testing.T) {
+	for _, in := range []string{
+		"a[]=1&a[]=2&a[b]=x&c=3",
+		"a[][b]=c&a[][b]=d&q=%22quoted%22%3B",
+		"x[k][1][]=v&x[k][0]=w",
+	} {
+		parsed, _ := ParseStr(in)
+		s, err := Serialize(parsed)
+		if err != nil {
+			t.Fatalf("%q: serialize: %v", in, err)
+		}
+		got, err := Unserialize(s)
+		if err != nil {
+			t.Fatalf("%q: unserialize %s: %v", in, s, err)
+		}
+		if !reflect.DeepEqual(got, parsed) {
+			t.Fatalf("%q: got %#v, want %#v", in, got, parsed)
+		}
+	}
+}
+
+func TestSerialize_SkipsHolesLikePHP(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string // serialize() of the $_GET PHP builds for query
+	}{
+		{"a[1]=x", `a:1:{s:1:"a";a:1:{i:1;s:1:"x";}}`},
+		{"a[0]=b&a[2]=c", `a:1:{s:1:"a";a:2:{i:0;s:1:"b";i:2;s:1:"c";}}`},
+		{"a[-7]=x&a[k]=y&a[07]=z", `a:1:{s:1:"a";a:3:{i:-7;s:1:"x";s:1:"k";s:1:"y";s:2:"07";s:1:"z";}}`},
+		{"a[2][]=x&b=1", `a:2:{s:1:"a";a:1:{i:2;a:1:{i:0;s:1:"x";}}s:1:"b";s:1:"1";}`},
+	}
+	for _, tt := range tests {
+		parsed, _ := ParseStr(tt.query)
+		got, err := Serialize(parsed)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.query, err)
+		}
+		if got != tt.want {
+			t.Fatalf("%q: got %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestUnserialize_Scalars(t *testing.T) {
+	got, err := Unserialize(`a:5:{i:0;b:0;i:1;i:-4;i:2;d:0.5;s:1:"k";N;i:0;s:0:"";}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the repeated key 0 overwrites in place; "k" makes the array a map
+	want := map[string]any{"0": "", "1": -4, "2": 0.5, "k": nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestUnserialize_Errors(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   error
+		offset int
+	}{
+		{`O:8:"stdClass":0:{}`, ErrObjectPayload, 0},
+		{`a:1:{i:0;R:1;}`, ErrObjectPayload, 9},
+		{`s:5:"abc";`, ErrMalformedPayload, 10},
+		{`a:2:{i:0;N;}`, ErrMalformedPayload, 11},
+		{`i:1;i:2;`, ErrMalformedPayload, 4},
+		{`a:1:{d:1;N;}`, ErrMalformedPayload, 5},
+		{`a:99999999999:{}`, ErrMalformedPayload, 15},
+		{``, ErrMalformedPayload, 0},
+	}
+	for _, tt := range tests {
+		_, err := Unserialize(tt.in)
+		var ue *UnserializeError
+		if !errors.As(err, &ue) || !errors.Is(err, tt.want) || ue.Offset != tt.offset {
+			t.Fatalf("%q: got %v, want %v at offset %d", tt.in, err, tt.want, tt.offset)
+		}
+	}
+}
+
+func TestUnserialize_Limits(t *testing.T) {
+	deep := strings.Repeat("a:1:{i:0;", 5) + "N;" + strings.Repeat("}", 5)
+	if _, err := UnserializeWithOptions(deep, UnserializeOptions{MaxDepth: 5}); err != nil {
+		t.Fatalf("depth 5: unexpected error: %v", err)
+	}
+	if _, err := UnserializeWithOptions(deep, UnserializeOptions{MaxDepth: 4}); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("depth 4: got %v, want ErrLimitExceeded", err)
+	}
+	if _, err := UnserializeWithOptions(`s:3:"abc";`, UnserializeOptions{MaxBytes: 5}); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("bytes: got %v, want ErrLimitExceeded", err)
+	}
+}
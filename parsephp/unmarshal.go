@@ -0,0 +1,330 @@
+package parsephp
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldError reports a value that could not be stored into the Unmarshal target.
+// Path is the bracket path of the offending value, e.g. "a[items][3][qty]".
+type FieldError struct {
+	Path string
+	Msg  string
+	Err  error // underlying conversion error, if any
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Msg
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Unmarshal parses query with ParseStrWithOptions and stores the result into v, which must be a
+// non-nil pointer (usually to a struct).
+//
+// Mapping rules:
+// - struct fields are matched by their `php:"name"` tag, or by the Go field name when untagged;
+//   `php:"-"` skips a field, embedded structs are flattened, unknown keys are ignored
+// - slices and arrays take []any by index; nil holes leave zero values
+// - maps take any container; keys are converted from text like values
+// - pointers are allocated as needed; interface{} receives the raw parsed value
+// - string leaves are converted by text: ints, uints, floats, bools ("1"/"0", "true"/"false", "on"/"off",
+//   "yes"/"no") and any encoding.TextUnmarshaler such as time.Time (RFC 3339);
+//   an empty string leaves numbers, bools and text types at their zero value
+//
+// Conversion failures are returned as *FieldError carrying the bracket path.
+func Unmarshal(query string, v any, opts Options) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("unmarshal: %w: target must be a non-nil pointer, got %T", ErrUnsupportedType, v)
+	}
+	root, err := ParseStrWithOptions(query, opts)
+	if err != nil {
+		return err
+	}
+	return decodeValue(root, rv.Elem(), "")
+}
+
+// ParseInto is the generic form of Unmarshal.
+func ParseInto[T any](query string, opts Options) (T, error) {
+	var out T
+	err := Unmarshal(query, &out, opts)
+	return out, err
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+var leafType = reflect.TypeOf(Leaf{})
+
+// decodeValue stores src (a string, Leaf, nil, []any or map[string]any) into dst.
+func decodeValue(src any, dst reflect.Value, path string) error {
+	if src == nil {
+		return nil
+	}
+	if dst.Kind() == reflect.Pointer {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return decodeValue(src, dst.Elem(), path)
+	}
+	if leaf, ok := src.(Leaf); ok {
+		if dst.Type() == leafType {
+			dst.Set(reflect.ValueOf(leaf))
+			return nil
+		}
+		src = leaf.Value
+	}
+	if dst.CanAddr() && dst.Addr().Type().Implements(textUnmarshalerType) {
+		s, ok := src.(string)
+		if !ok {
+			return mismatch(path, "string", src)
+		}
+		if s == "" {
+			return nil
+		}
+		if err := dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return &FieldError{Path: path, Msg: "invalid " + dst.Type().String(), Err: err}
+		}
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return &FieldError{Path: path, Msg: "unsupported field type " + dst.Type().String()}
+		}
+		dst.Set(reflect.ValueOf(src))
+		return nil
+	case reflect.Struct:
+		m, ok := src.(map[string]any)
+		if !ok {
+			return mismatch(path, "array with named keys", src)
+		}
+		return decodeStruct(m, dst, path)
+	case reflect.Map:
+		return decodeMap(src, dst, path)
+	case reflect.Slice, reflect.Array:
+		return decodeList(src, dst, path)
+	}
+
+	s, ok := src.(string)
+	if !ok {
+		return mismatch(path, "string", src)
+	}
+	return decodeScalar(s, dst, path)
+}
+
+// decodeScalar converts a string leaf into a basic kind.
+func decodeScalar(s string, dst reflect.Value, path string) error {
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(s)
+		return nil
+	case reflect.Bool:
+		switch strings.ToLower(s) {
+		case "", "0", "false", "off", "no":
+			dst.SetBool(false)
+		case "1", "true", "on", "yes":
+			dst.SetBool(true)
+		default:
+			return &FieldError{Path: path, Msg: "invalid boolean"}
+		}
+		return nil
+	}
+	if s == "" {
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		if err != nil {
+			return numError(path, "integer", err)
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, dst.Type().Bits())
+		if err != nil {
+			return numError(path, "unsigned integer", err)
+		}
+		dst.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, dst.Type().Bits())
+		if err != nil {
+			return numError(path, "float", err)
+		}
+		dst.SetFloat(f)
+	default:
+		return &FieldError{Path: path, Msg: "unsupported field type " + dst.Type().String()}
+	}
+	return nil
+}
+
+// decodeStruct fills the fields of dst from m by php tag / field name.
+func decodeStruct(m map[string]any, dst reflect.Value, path string) error {
+	fields := structFields(dst.Type())
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names) // deterministic error reporting
+	for _, name := range names {
+		index, ok := fields[name]
+		if !ok {
+			continue
+		}
+		fieldPath := joinPath(path, name)
+		field, err := fieldByIndexAlloc(dst, index, fieldPath)
+		if err != nil {
+			return err
+		}
+		if err := decodeValue(m[name], field, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeMap fills a map with string-convertible keys from a parsed map or slice.
+func decodeMap(src any, dst reflect.Value, path string) error {
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMap(dst.Type()))
+	}
+	var keys []string
+	var values []any
+	switch c := src.(type) {
+	case map[string]any:
+		for k := range c {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			values = append(values, c[k])
+		}
+	case []any:
+		for i, elem := range c {
+			if elem == nil {
+				continue
+			}
+			keys = append(keys, strconv.Itoa(i))
+			values = append(values, elem)
+		}
+	default:
+		return mismatch(path, "array", src)
+	}
+	kt, vt := dst.Type().Key(), dst.Type().Elem()
+	for i, k := range keys {
+		elemPath := joinPath(path, k)
+		kv := reflect.New(kt).Elem()
+		if err := decodeScalar(k, kv, elemPath); err != nil {
+			return &FieldError{Path: elemPath, Msg: "invalid key for " + dst.Type().String(), Err: err}
+		}
+		ev := reflect.New(vt).Elem()
+		if err := decodeValue(values[i], ev, elemPath); err != nil {
+			return err
+		}
+		dst.SetMapIndex(kv, ev)
+	}
+	return nil
+}
+
+// decodeList fills a slice or array from a parsed slice.
+// A map never qualifies: ParseStr only produces one once a named key appeared.
+func decodeList(src any, dst reflect.Value, path string) error {
+	elems, ok := src.([]any)
+	if !ok {
+		return mismatch(path, "list", src)
+	}
+	if dst.Kind() == reflect.Array {
+		if len(elems) > dst.Len() {
+			return &FieldError{Path: joinPath(path, strconv.Itoa(dst.Len())), Msg: "index out of range"}
+		}
+	} else if dst.Len() < len(elems) {
+		grown := reflect.MakeSlice(dst.Type(), len(elems), len(elems))
+		reflect.Copy(grown, dst)
+		dst.Set(grown)
+	}
+	for i, elem := range elems {
+		if err := decodeValue(elem, dst.Index(i), joinPath(path, strconv.Itoa(i))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structFields maps the query names of t's fields to their (possibly promoted) index paths.
+func structFields(t reflect.Type) map[string][]int {
+	out := make(map[string][]int)
+	for _, f := range reflect.VisibleFields(t) {
+		tag, hasTag := f.Tag.Lookup("php")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		if f.Anonymous && !hasTag {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				continue // flattened: its fields are listed by VisibleFields
+			}
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		if prev, ok := out[name]; ok && len(prev) <= len(f.Index) {
+			continue // shallower field wins, like encoding/json
+		}
+		out[name] = f.Index
+	}
+	return out
+}
+
+// fieldByIndexAlloc is reflect.Value.FieldByIndex allocating nil embedded pointers on the way.
+// A nil pointer to an unexported embedded struct cannot be allocated and is reported at path,
+// as encoding/json does.
+func fieldByIndexAlloc(v reflect.Value, index []int, path string) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, &FieldError{Path: path, Msg: "cannot set embedded pointer to unexported struct " + v.Type().Elem().String()}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// joinPath appends key to a bracket path: the first key is the base, the rest are bracketed.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "[" + key + "]"
+}
+
+func mismatch(path, want string, got any) error {
+	kind := "string"
+	switch got.(type) {
+	case []any, map[string]any:
+		kind = "array"
+	}
+	return &FieldError{Path: path, Msg: fmt.Sprintf("expected %s, got %s", want, kind)}
+}
+
+func numError(path, what string, err error) error {
+	if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+		return &FieldError{Path: path, Msg: what + " out of range", Err: err}
+	}
+	return &FieldError{Path: path, Msg: "invalid " + what, Err: err}
+}
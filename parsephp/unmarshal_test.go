@@ -0,0 +1,141 @@
+package parsephp
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type unmarshalItem struct {
+	SKU string `php:"sku"`
+	Qty int    `php:"qty"`
+}
+
+type unmarshalMeta struct {
+	Source string `php:"source"`
+}
+
+type unmarshalOrder struct {
+	unmarshalMeta
+	ID      int64             `php:"id"`
+	Price   *float64          `php:"price"`
+	Gift    bool              `php:"gift"`
+	Items   []unmarshalItem   `php:"items"`
+	Tags    map[string]string `php:"tags"`
+	At      time.Time         `php:"at"`
+	Raw     any               `php:"raw"`
+	Skipped string            `php:"-"`
+	Note    string
+}
+
+func TestUnmarshal_NestedStruct(t *testing.T) {
+	q := "id=42&price=9.5&gift=on&items[][sku]=A&items[][sku]=B&items[1][qty]=3" +
+		"&tags[x]=1&at=2024-05-01T10:00:00Z&raw[k]=v&Skipped=no&Note=hi&source=web&unknown=1"
+	got, err := ParseInto[unmarshalOrder](q, DefaultOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	price := 9.5
+	want := unmarshalOrder{
+		unmarshalMeta: unmarshalMeta{Source: "web"},
+		ID:            42,
+		Price:         &price,
+		Gift:          true,
+		Items:         []unmarshalItem{{SKU: "A"}, {SKU: "B", Qty: 3}},
+		Tags:          map[string]string{"x": "1"},
+		At:            time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Raw:           map[string]any{"k": "v"},
+		Note:          "hi",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestUnmarshal_FieldPathErrors(t *testing.T) {
+	type inner struct {
+		Items []unmarshalItem `php:"items"`
+	}
+	type outer struct {
+		A inner `php:"a"`
+	}
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"a[items][3][qty]=many", "a[items][3][qty]: invalid integer"},
+		{"a[items][0][qty]=99999999999999999999", "a[items][0][qty]: integer out of range"},
+		{"a[items]=x", "a[items]: expected list, got string"},
+		{"a=x", "a: expected array with named keys, got string"},
+	}
+	for _, c := range cases {
+		var v outer
+		err := Unmarshal(c.in, &v, DefaultOptions)
+		var fe *FieldError
+		if !errors.As(err, &fe) {
+			t.Fatalf("%q: got %v, want *FieldError", c.in, err)
+		}
+		if err.Error() != c.want {
+			t.Fatalf("%q: got %q, want %q", c.in, err.Error(), c.want)
+		}
+	}
+
+	var v outer
+	err := Unmarshal("a[items][0][qty]=x", &v, DefaultOptions)
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("got %v, want wrapped strconv.ErrSyntax", err)
+	}
+}
+
+func TestUnmarshal_MapsArraysAndHoles(t *testing.T) {
+	type target struct {
+		ByID  map[int]string `php:"by_id"`
+		Fixed [2]string      `php:"fixed"`
+		Nums  []int          `php:"nums"`
+	}
+	got, err := ParseInto[target]("by_id[7]=x&by_id[9]=y&fixed[]=a&fixed[]=b&nums[0]=1&nums[2]=3", DefaultOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := target{ByID: map[int]string{7: "x", 9: "y"}, Fixed: [2]string{"a", "b"}, Nums: []int{1, 0, 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+
+	if _, err := ParseInto[target]("fixed[]=a&fixed[]=b&fixed[]=c", DefaultOptions); err == nil || err.Error() != "fixed[2]: index out of range" {
+		t.Fatalf("got %v, want fixed[2]: index out of range", err)
+	}
+}
+
+func TestUnmarshal_InvalidTarget(t *testing.T) {
+	var v struct{}
+	if err := Unmarshal("a=1", v, DefaultOptions); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("got %v, want ErrUnsupportedType", err)
+	}
+}
+
+func TestUnmarshal_NilEmbeddedUnexportedPointer(t *testing.T) {
+	type inner struct {
+		X int `php:"x"`
+	}
+	type target struct {
+		*inner
+		Y int `php:"y"`
+	}
+	got, err := ParseInto[target]("y=2", DefaultOptions)
+	if err != nil || got.Y != 2 || got.inner != nil {
+		t.Fatalf("got %#v, %v; want Y=2 and nil inner", got, err)
+	}
+
+	var v target
+	err = Unmarshal("x=1&y=2", &v, DefaultOptions)
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Path != "x" {
+		t.Fatalf("got %v, want *FieldError at x", err)
+	}
+	if want := "x: cannot set embedded pointer to unexported struct parsephp.inner"; err.Error() != want {
+		t.Fatalf("got %q, want %q", err.Error(), want)
+	}
+}
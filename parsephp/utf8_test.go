@@ -0,0 +1,81 @@
+package parsephp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestInvalidUTF8_Policies(t *testing.T) {
+	const in = "ok=%C3%A9&v=a%FFb&k%FE=1&a[%C3]=2&raw=\xff"
+	cases := []struct {
+		name   string
+		policy InvalidUTF8Policy
+		out    map[string]any
+	}{
+		{"keep", InvalidUTF8Keep,
+			map[string]any{"ok": "é", "v": "a\xffb", "k\xfe": "1", "a": map[string]any{"\xc3": "2"}, "raw": "\xff"}},
+		{"replace", InvalidUTF8Replace,
+			map[string]any{"ok": "é", "v": "a\uFFFDb", "k\uFFFD": "1", "a": map[string]any{"\uFFFD": "2"}, "raw": "\uFFFD"}},
+		{"drop", InvalidUTF8Drop, map[string]any{"ok": "é"}},
+	}
+	for _, c := range cases {
+		got, err := ParseStrWithOptions(in, Options{Separators: []rune{'&'}, InvalidUTF8: c.policy})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(got, c.out) {
+			t.Fatalf("%s: got %#v, want %#v", c.name, got, c.out)
+		}
+	}
+}
+
+func TestInvalidUTF8_Error(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		opts Options
+		want UTF8Error
+	}{
+		{"value", "ok=1&v=a%FFb", Options{InvalidUTF8: InvalidUTF8Error}, UTF8Error{Offset: 5, Pair: "v=a%FFb", Part: "value"}},
+		{"token", "ok=1&a[b][%FE]=1", Options{InvalidUTF8: InvalidUTF8Error}, UTF8Error{Offset: 5, Pair: "a[b][%FE]=1", Part: "key"}},
+		{"strict", "x=%E9", Options{StrictDecode: true, InvalidUTF8: InvalidUTF8Error}, UTF8Error{Offset: 0, Pair: "x=%E9", Part: "value"}},
+	}
+	for _, c := range cases {
+		_, err := ParseStrWithOptions(c.in, c.opts)
+		var ue *UTF8Error
+		if !errors.As(err, &ue) || !errors.Is(err, ErrInvalidUTF8) {
+			t.Fatalf("%s: got %v, want *UTF8Error", c.name, err)
+		}
+		if *ue != c.want {
+			t.Fatalf("%s: got %+v, want %+v", c.name, *ue, c.want)
+		}
+	}
+
+	// StrictDecode only checks percent-escapes: the zero policy still keeps the bytes
+	got, err := ParseStrWithOptions("x=%E9", Options{StrictDecode: true})
+	if err != nil || !reflect.DeepEqual(got, map[string]any{"x": "\xe9"}) {
+		t.Fatalf("strict default: got %#v, %v; want the byte kept", got, err)
+	}
+	if _, err := ParseStrWithOptions("x=%E9", Options{StrictDecode: true, InvalidUTF8: InvalidUTF8Replace}); err != nil {
+		t.Fatalf("strict with replace: unexpected error: %v", err)
+	}
+	// a charset decoder produces valid UTF-8, so nothing is left to reject
+	if _, err := ParseStrWithOptions("x=%E9", Options{StrictDecode: true, Charset: Latin1}); err != nil {
+		t.Fatalf("strict with charset: unexpected error: %v", err)
+	}
+}
+
+func TestInvalidUTF8_Diagnostics(t *testing.T) {
+	_, warnings, err := ParseStrWithDiagnostics("a[x]=%FF&b%FF=1", Options{InvalidUTF8: InvalidUTF8Drop})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Warning{
+		{Kind: WarnInvalidUTF8, Offset: 0, Pair: "a[x]=%FF", Path: "a[x]", Lost: "\xff", Detail: "pair with malformed UTF-8 in value dropped"},
+		{Kind: WarnInvalidUTF8, Offset: 9, Pair: "b%FF=1", Path: "b\uFFFD", Lost: "1", Detail: "pair with malformed UTF-8 in key dropped"},
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Fatalf("got %#v, want %#v", warnings, want)
+	}
+}